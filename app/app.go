@@ -0,0 +1,236 @@
+package app
+
+import (
+	base "github.com/tendermint/basecoin/types"
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/go-merkle"
+	"github.com/tendermint/go-wire"
+	gm "github.com/tendermint/governmint/gov"
+	"github.com/tendermint/governmint/types"
+	tmsp "github.com/tendermint/tmsp/types"
+)
+
+// GovernmintApp runs Governmint as a standalone TMSP application.
+// Without basecoin, proposal deposits are paid from balances kept by the app,
+// which are set at genesis with the "account" option.
+type GovernmintApp struct {
+	gov   *gm.Governmint
+	state merkle.Tree
+	store base.KVStore
+}
+
+func NewGovernmintApp() *GovernmintApp {
+	state := merkle.NewIAVLTree(0, nil)
+	return &GovernmintApp{
+		gov:   gm.NewGovernmint(),
+		state: state,
+		store: NewMerkleStore(state),
+	}
+}
+
+// TMSP::Info
+func (app *GovernmintApp) Info() string {
+	return Fmt("Governmint v%v height:%v size:%v",
+		gm.Version, app.gov.GovMeta.Height, app.state.Size())
+}
+
+// TMSP::SetOption
+func (app *GovernmintApp) SetOption(key string, value string) (log string) {
+	if key == "account" {
+		// Read account
+		var acc = new(Account)
+		err := wire.ReadJSONBytes([]byte(value), acc)
+		if err != nil {
+			return "Error decoding account: " + err.Error()
+		}
+		for _, coin := range acc.Coins {
+			if coin.Denom == "" || coin.Amount <= 0 {
+				return Fmt("Invalid account balance %v", acc.Coins)
+			}
+		}
+		// Save balance
+		app.setBalance(acc.Addr, acc.Coins)
+		return "Success"
+	}
+	return app.gov.SetOption(app.store, key, value)
+}
+
+// TMSP::AppendTx
+// The proposal deposit is taken from the proposer's balance
+// once the proposal is created.
+func (app *GovernmintApp) AppendTx(txBytes []byte) tmsp.Result {
+	ctx, res := app.callContext(txBytes)
+	if !res.IsOK() {
+		return res
+	}
+	res = app.gov.RunTx(app.store, ctx, txBytes)
+	if res.IsOK() && len(ctx.Coins) > 0 {
+		balance, _ := subCoins(app.Balance(ctx.CallerAddress), ctx.Coins)
+		app.setBalance(ctx.CallerAddress, balance)
+	}
+	return res
+}
+
+// TMSP::CheckTx
+func (app *GovernmintApp) CheckTx(txBytes []byte) tmsp.Result {
+	ctx, res := app.callContext(txBytes)
+	if !res.IsOK() {
+		return res
+	}
+	return app.gov.CheckTx(app.store, ctx, txBytes)
+}
+
+// Returns the context of a tx, with the deposit a ProposalTx must pay.
+// Fails if the proposer's balance doesn't cover it.
+func (app *GovernmintApp) callContext(txBytes []byte) (base.CallContext, tmsp.Result) {
+	var tx types.Tx
+	err := wire.ReadBinaryBytes(txBytes, &tx)
+	if err != nil {
+		// Governmint reports the error
+		return base.CallContext{}, tmsp.OK
+	}
+	proposalTx, ok := tx.(*types.ProposalTx)
+	if !ok {
+		return base.CallContext{}, tmsp.OK
+	}
+	deposit := app.gov.ProposalDeposit(app.store, proposalTx)
+	if len(deposit) == 0 {
+		return base.CallContext{}, tmsp.OK
+	}
+	if _, ok := subCoins(app.Balance(proposalTx.EntityAddr), deposit); !ok {
+		return base.CallContext{}, tmsp.NewError(tmsp.CodeType_InsufficientFunds,
+			Fmt("Proposer %X cannot pay the deposit of %v", proposalTx.EntityAddr, deposit))
+	}
+	return base.CallContext{CallerAddress: proposalTx.EntityAddr, Coins: deposit}, tmsp.OK
+}
+
+// TMSP::Commit
+// Returns the Merkle root hash of the state.
+func (app *GovernmintApp) Commit() tmsp.Result {
+	hash := app.state.Save()
+	return tmsp.NewResultOK(hash, "")
+}
+
+// TMSP::Query
+func (app *GovernmintApp) Query(queryBytes []byte) tmsp.Result {
+	return app.gov.Query(app.store, queryBytes)
+}
+
+// TMSP::InitChain
+func (app *GovernmintApp) InitChain(validators []*tmsp.Validator) {
+	app.gov.InitChain(app.store, validators)
+}
+
+// TMSP::BeginBlock
+// TMSP only passes the height, so blocks have no known time and
+// proposals with voting times are rejected.
+func (app *GovernmintApp) BeginBlock(height uint64) {
+	app.gov.BeginBlock(app.store, height)
+}
+
+// TMSP::EndBlock
+// Refunded deposits are returned to the proposers, forfeited ones are kept.
+func (app *GovernmintApp) EndBlock(height uint64) []*tmsp.Validator {
+	diffs := app.gov.EndBlock(app.store, height)
+	for _, settlement := range app.gov.GetDepositSettlements(app.store, height) {
+		if settlement.Refunded {
+			balance := addCoins(app.Balance(settlement.Proposer), settlement.Deposit)
+			app.setBalance(settlement.Proposer, balance)
+		}
+	}
+	return diffs
+}
+
+//----------------------------------------
+
+// Account sets the balance of an address at genesis.
+type Account struct {
+	Addr  []byte     `json:"addr"`
+	Coins base.Coins `json:"coins"`
+}
+
+func AccountKey(addr []byte) []byte {
+	return append([]byte("app/acc/"), addr...)
+}
+
+// Returns the address's balance, empty if none was set.
+func (app *GovernmintApp) Balance(addr []byte) base.Coins {
+	bz := app.store.Get(AccountKey(addr))
+	if len(bz) == 0 {
+		return nil
+	}
+	var coins base.Coins
+	err := wire.ReadBinaryBytes(bz, &coins)
+	if err != nil {
+		PanicCrisis(err)
+	}
+	return coins
+}
+
+func (app *GovernmintApp) setBalance(addr []byte, coins base.Coins) {
+	app.store.Set(AccountKey(addr), wire.BinaryBytes(coins))
+}
+
+// Returns coins with the amounts of other added, by denomination.
+func addCoins(coins base.Coins, other base.Coins) base.Coins {
+	sum := append(base.Coins{}, coins...)
+	for _, coin := range other {
+		found := false
+		for i := range sum {
+			if sum[i].Denom == coin.Denom {
+				sum[i].Amount += coin.Amount
+				found = true
+				break
+			}
+		}
+		if !found {
+			sum = append(sum, coin)
+		}
+	}
+	return sum
+}
+
+// Returns coins with the amounts of other taken away, by denomination.
+// Returns false if coins doesn't cover other.
+func subCoins(coins base.Coins, other base.Coins) (base.Coins, bool) {
+	diff := append(base.Coins{}, coins...)
+	for _, coin := range other {
+		found := false
+		for i := range diff {
+			if diff[i].Denom == coin.Denom && diff[i].Amount >= coin.Amount {
+				diff[i].Amount -= coin.Amount
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, false
+		}
+	}
+	return diff, true
+}
+
+//----------------------------------------
+
+// MerkleStore implements base.KVStore on top of a merkle.Tree.
+// Setting an empty value removes the key.
+type MerkleStore struct {
+	tree merkle.Tree
+}
+
+func NewMerkleStore(tree merkle.Tree) *MerkleStore {
+	return &MerkleStore{tree}
+}
+
+func (ms *MerkleStore) Set(key []byte, value []byte) {
+	if len(value) == 0 {
+		ms.tree.Remove(key)
+		return
+	}
+	ms.tree.Set(key, value)
+}
+
+func (ms *MerkleStore) Get(key []byte) (value []byte) {
+	_, value, _ = ms.tree.Get(key)
+	return value
+}
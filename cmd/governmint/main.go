@@ -0,0 +1,29 @@
+package main
+
+import (
+	"flag"
+
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/governmint/app"
+	"github.com/tendermint/tmsp/server"
+)
+
+func main() {
+
+	addrPtr := flag.String("address", "tcp://0.0.0.0:46658", "Listen address")
+	tmspPtr := flag.String("tmsp", "socket", "socket | grpc")
+	flag.Parse()
+
+	// Start the listener
+	srv, err := server.NewServer(*addrPtr, *tmspPtr, app.NewGovernmintApp())
+	if err != nil {
+		Exit(err.Error())
+	}
+
+	// Wait forever
+	TrapSignal(func() {
+		// Cleanup
+		srv.Stop()
+	})
+
+}
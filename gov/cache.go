@@ -0,0 +1,55 @@
+package gov
+
+import (
+	"sort"
+
+	base "github.com/tendermint/basecoin/types"
+)
+
+// KVCache buffers writes on top of a KVStore.
+// Nothing reaches the underlying store until Sync is called,
+// so a failed tx can simply drop the cache.
+type KVCache struct {
+	store base.KVStore
+	cache map[string][]byte
+}
+
+func NewKVCache(store base.KVStore) *KVCache {
+	return &KVCache{
+		store: store,
+		cache: make(map[string][]byte),
+	}
+}
+
+// Implements base.KVStore
+func (kvc *KVCache) Set(key []byte, value []byte) {
+	kvc.cache[string(key)] = value
+}
+
+// Implements base.KVStore
+func (kvc *KVCache) Get(key []byte) (value []byte) {
+	value, ok := kvc.cache[string(key)]
+	if ok {
+		return value
+	}
+	return kvc.store.Get(key)
+}
+
+// Write all buffered values to the underlying store.
+// Keys are written in sorted order so that the result is deterministic.
+func (kvc *KVCache) Sync() {
+	keys := make([]string, 0, len(kvc.cache))
+	for key := range kvc.cache {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		kvc.store.Set([]byte(key), kvc.cache[key])
+	}
+	kvc.Reset()
+}
+
+// Drop all buffered values.
+func (kvc *KVCache) Reset() {
+	kvc.cache = make(map[string][]byte)
+}
@@ -2,8 +2,10 @@ package gov
 
 import (
 	"bytes"
-	"fmt"
-	"github.com/tendermint/go-common"
+	"crypto/sha256"
+	"sort"
+	"strconv"
+	"strings"
 
 	base "github.com/tendermint/basecoin/types"
 	. "github.com/tendermint/go-common"
@@ -14,12 +16,42 @@ import (
 )
 
 const (
-	Version        = "0.1"
-	MaxVotingPower = 1<<53 - 1
+	Version             = "0.1"
+	MaxVotingPower      = 1<<53 - 1
+	DefaultRevealPeriod = 100
+
+	DefaultConvictionFactor = 10
+	DefaultVetoPeriod       = 100
+
+	DefaultMinVotingPeriod          = 1
+	DefaultMaxVotingPeriod          = 100000
+	DefaultMaxStartDelay            = 1000
+	DefaultMinVotingDuration        = 0
+	DefaultMaxVotingDuration        = 90 * 24 * 60 * 60
+	DefaultEmergencyMinVotingPeriod = 0
+
+	DefaultMaxActiveProposalsPerProposer = 10
+	DefaultMaxActiveProposalsPerGroup    = 100
+
+	MaxTitleLength       = 140
+	MaxDescriptionLength = 10000
+	MaxTextLength        = 10000
+	MaxLinks             = 10
+	MaxLinkLength        = 256
+	MaxContentHashLength = 64
+
+	MaxEntityNameLength    = 64
+	MaxEntityContactLength = 256
+	MaxEntityWebsiteLength = 256
+
+	MaxGroupNameLength = 64
 )
 
+var DefaultEmergencyThreshold = types.Fraction{Numerator: 3, Denominator: 4}
+
 type Governmint struct {
 	*types.GovMeta
+	blockTime uint64 // Set by SetBlockTime for the next BeginBlock
 }
 
 func NewGovernmint() *Governmint {
@@ -31,14 +63,54 @@ func NewGovernmint() *Governmint {
 	return gov
 }
 
+func DefaultGovParams() *types.GovParams {
+	return &types.GovParams{
+		MaxVotingPower: MaxVotingPower,
+		RevealPeriod:   DefaultRevealPeriod,
+
+		ConvictionFactor: DefaultConvictionFactor,
+		VetoPeriod:       DefaultVetoPeriod,
+
+		MinVotingPeriod: DefaultMinVotingPeriod,
+		MaxVotingPeriod: DefaultMaxVotingPeriod,
+		MaxStartDelay:   DefaultMaxStartDelay,
+
+		MinVotingDuration: DefaultMinVotingDuration,
+		MaxVotingDuration: DefaultMaxVotingDuration,
+
+		MaxActiveProposalsPerProposer: DefaultMaxActiveProposalsPerProposer,
+		MaxActiveProposalsPerGroup:    DefaultMaxActiveProposalsPerGroup,
+
+		EmergencyMinVotingPeriod: DefaultEmergencyMinVotingPeriod,
+		EmergencyThreshold:       DefaultEmergencyThreshold,
+	}
+}
+
+// Implements basecoin.Plugin
+// Options are applied atomically, the log is "Success" or the reason for failure.
 func (gov *Governmint) SetOption(store base.KVStore, key string, value string) (log string) {
+	res := gov.runCached(store, func(cache base.KVStore) tmsp.Result {
+		return gov.setOption(cache, key, value)
+	})
+	if !res.IsOK() {
+		return res.Log
+	}
+	return "Success"
+}
+
+func (gov *Governmint) setOption(store base.KVStore, key string, value string) tmsp.Result {
 	switch key {
 	case "admin":
 		// Read entity
 		var entity = new(types.Entity)
 		err := wire.ReadJSONBytes([]byte(value), entity)
 		if err != nil {
-			return "Error decoding admin entity: " + err.Error()
+			return tmsp.ErrEncodingError.SetLog(
+				"Error decoding admin entity: " + err.Error())
+		}
+		// Ensure that the entity's profile is valid
+		if res := validateEntityProfile(entity.Profile, entity.PubKey); !res.IsOK() {
+			return res
 		}
 		// Save entity
 		gov.SetEntity(store, entity)
@@ -55,18 +127,59 @@ func (gov *Governmint) SetOption(store base.KVStore, key string, value string) (
 		}
 		// Save admin group
 		gov.SetGroup(store, adminGroup)
-		return "Success"
+		return tmsp.OK
 	case "entity":
 		// Read entity
 		var entity = new(types.Entity)
 		err := wire.ReadJSONBytes([]byte(value), entity)
 		if err != nil {
-			return "Error decoding entity: " + err.Error()
+			return tmsp.ErrEncodingError.SetLog(
+				"Error decoding entity: " + err.Error())
+		}
+		// Ensure that the entity's profile is valid
+		if res := validateEntityProfile(entity.Profile, entity.PubKey); !res.IsOK() {
+			return res
 		}
 		// Save entity
 		gov.SetEntity(store, entity)
+		return tmsp.OK
+	case "group":
+		// Read group
+		var group = new(types.Group)
+		err := wire.ReadJSONBytes([]byte(value), group)
+		if err != nil {
+			return tmsp.ErrEncodingError.SetLog(
+				"Error decoding group: " + err.Error())
+		}
+		// Ensure that the group is valid
+		if res := gov.validateGroup(store, group); !res.IsOK() {
+			return res
+		}
+		// Save group
+		gov.SetGroup(store, group)
+		return tmsp.OK
+	case "chain_id":
+		gov.GovMeta.ChainID = value
+		gov.SetGovMeta(store, gov.GovMeta)
+		return tmsp.OK
+	case "params":
+		// Read params, fields that are not given keep their current value
+		var params = gov.govParams(store)
+		err := wire.ReadJSONBytes([]byte(value), params)
+		if err != nil {
+			return tmsp.ErrEncodingError.SetLog(
+				"Error decoding params: " + err.Error())
+		}
+		// Ensure that the params are valid
+		if res := validateGovParams(params); !res.IsOK() {
+			return res
+		}
+		// Save params
+		gov.SetGovParams(store, params)
+		return tmsp.OK
 	}
-	return "Unrecognized governmint option key " + key
+	return tmsp.ErrUnknownRequest.SetLog(
+		"Unrecognized governmint option key " + key)
 }
 
 // Implements basecoin.Plugin
@@ -77,94 +190,85 @@ func (gov *Governmint) RunTx(store base.KVStore, ctx base.CallContext, txBytes [
 		return tmsp.ErrEncodingError.SetLog(
 			Fmt("Error parsing Governmint tx bytes: %v", err.Error()))
 	}
-	return gov.RunTxParsed(store, tx)
+	return gov.RunTxParsed(store, ctx, tx)
 }
 
-func (gov *Governmint) RunTxParsed(store base.KVStore, tx types.Tx) tmsp.Result {
-	switch tx := tx.(type) {
-	case *types.ProposalTx:
-		return gov.RunProposalTx(store, tx)
-	case *types.VoteTx:
-		return gov.RunVoteTx(store, tx)
-	default:
-		PanicSanity("Unknown tx type")
-		return tmsp.NewError(tmsp.CodeType_InternalError, "Unknown tx type")
-	}
+// Runs the tx against a KVCache.
+// The store is only written to if the tx succeeds.
+func (gov *Governmint) RunTxParsed(store base.KVStore, ctx base.CallContext, tx types.Tx) tmsp.Result {
+	return gov.runCached(store, func(cache base.KVStore) tmsp.Result {
+		switch tx := tx.(type) {
+		case *types.ProposalTx:
+			return gov.RunProposalTx(cache, ctx, tx)
+		case *types.VoteTx:
+			return gov.RunVoteTx(cache, tx)
+		case *types.VoteCommitTx:
+			return gov.RunVoteCommitTx(cache, tx)
+		case *types.VoteRevealTx:
+			return gov.RunVoteRevealTx(cache, tx)
+		case *types.VetoTx:
+			return gov.RunVetoTx(cache, tx)
+		case *types.EntityUpdateTx:
+			return gov.RunEntityUpdateTx(cache, tx)
+		default:
+			return tmsp.NewError(types.CodeType_GovUnknownTx, "Unknown tx type")
+		}
+	})
 }
 
-func (gov *Governmint) RunProposalTx(store base.KVStore, tx *types.ProposalTx) tmsp.Result {
-	// Ensure that proposer exists
-	entity, ok := gov.GetEntity(store, tx.EntityAddr)
-	if !ok {
-		return tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
-			Fmt("Entity %X unknown", tx.EntityAddr))
-	}
-	// Ensure signature is valid
-	signBytes := tx.SignBytes()
-	if !entity.PubKey.VerifyBytes(signBytes, tx.Signature) {
-		return tmsp.NewError(tmsp.CodeType_Unauthorized,
-			Fmt("Invalid signature"))
+// Run fn against a KVCache wrapping store.
+// If fn returns an error, none of its writes reach store and
+// the in-memory GovMeta is restored.
+func (gov *Governmint) runCached(store base.KVStore, fn func(base.KVStore) tmsp.Result) (res tmsp.Result) {
+	cache := NewKVCache(store)
+	govMeta := *gov.GovMeta
+	defer func() {
+		if res.IsOK() {
+			cache.Sync()
+		} else {
+			*gov.GovMeta = govMeta
+		}
+	}()
+	defer recoverGovError(&res)
+	return fn(cache)
+}
+
+// ctx.Coins are the proposer's deposit, required of proposers outside
+// the vote group's proposer group.
+func (gov *Governmint) RunProposalTx(store base.KVStore, ctx base.CallContext, tx *types.ProposalTx) tmsp.Result {
+	if res := gov.checkProposalTx(store, ctx.Coins, tx); !res.IsOK() {
+		return res
 	}
-	// Ensure that the proposal is valid
-	tmspErr := gov.validateProposal(store, tx.Proposal, entity)
-	if !tmspErr.IsOK() {
-		return tmspErr
+	if res := gov.checkProposalLimits(store, tx.EntityAddr, tx.Proposal.VoteGroupID); !res.IsOK() {
+		return res
 	}
-	// Good! Create a new proposal
+	// Good! Create a new proposal with the next ID
+	gov.GovMeta.LastProposalID++
+	gov.SetGovMeta(store, gov.GovMeta)
 	proposal := tx.Proposal
+	proposal.ID = strconv.FormatUint(gov.GovMeta.LastProposalID, 10)
 	aProposal := &types.ActiveProposal{
 		Proposal:    proposal,
 		SignedVotes: nil,
+		Proposer:    tx.EntityAddr,
+		Deposit:     ctx.Coins,
 	}
 	gov.SetActiveProposal(store, aProposal)
-	return tmsp.NewResultOK(nil, "Proposal created")
+	gov.addActiveProposalID(store, proposal.ID)
+	gov.SetProposalHashID(store, proposalHash(gov.GovMeta.ChainID, tx), proposal.ID)
+	return tmsp.NewResultOK([]byte(proposal.ID), "Proposal created")
 }
 
 func (gov *Governmint) RunVoteTx(store base.KVStore, tx *types.VoteTx) tmsp.Result {
-	// Ensure that voter exists
-	entity, ok := gov.GetEntity(store, tx.Vote.EntityAddr)
-	if !ok {
-		return tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
-			Fmt("Entity %X unknown", tx.Vote.EntityAddr))
-	}
-	// Ensure signature is valid
-	signBytes := tx.SignBytes()
-	if !entity.PubKey.VerifyBytes(signBytes, tx.Signature) {
-		return tmsp.NewError(tmsp.CodeType_Unauthorized,
-			Fmt("Invalid signature"))
-	}
-	// Ensure that the proposal exists
-	aProposal, ok := gov.GetActiveProposal(store, tx.Vote.ProposalID)
-	if !ok {
-		return tmsp.NewError(tmsp.CodeType_GovUnknownProposal,
-			Fmt("Unknown proposal %v", tx.Vote.ProposalID))
-	}
-	// Ensure that the vote's height is <= current height
-	if !(tx.Vote.Height <= gov.GovMeta.Height) {
-		return tmsp.NewError(tmsp.CodeType_GovInvalidVote,
-			Fmt("Vote height is invalid"))
+	aProposal, res := gov.checkVoteTx(store, tx)
+	if !res.IsOK() {
+		return res
 	}
-	// Ensure that the vote's height matches the proposal's range
-	if !(aProposal.StartHeight <= tx.Vote.Height &&
-		tx.Vote.Height <= aProposal.EndHeight) {
-		return tmsp.NewError(tmsp.CodeType_GovInvalidVote,
-			Fmt("Vote height is invalid"))
-	}
-	// Fetch the proposal's voting group
-	voteGroup, ok := gov.GetGroup(store, aProposal.VoteGroupID)
-	if !ok {
-		return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
-			Fmt("Vote group with id %v doesn't exist", aProposal.VoteGroupID))
-	}
-	// Ensure that the voter belongs to the voting group
-	if !isMemberOf(voteGroup, entity.Addr) {
-		return tmsp.NewError(tmsp.CodeType_GovInvalidMember,
-			Fmt("Voter %v not a member of %v", entity.Addr, voteGroup.ID))
-	}
-	// Ensure that the voter hasn't already voted
-	if exists, _ := hasVoted(aProposal, entity.Addr); exists {
-		return tmsp.NewError(tmsp.CodeType_GovDuplicateVote,
-			Fmt("Voter %v already voted", entity.Addr))
+	// A new vote on a conviction proposal replaces the standing vote
+	groupID := voteGroupID(aProposal, tx.Vote)
+	if exists, i := hasVoted(aProposal, groupID, tx.Vote.EntityAddr); exists {
+		gov.unlockVoteCredits(store, aProposal, aProposal.SignedVotes[i].Vote)
+		aProposal.SignedVotes = append(aProposal.SignedVotes[:i], aProposal.SignedVotes[i+1:]...)
 	}
 	// Good! Add a SignedVote
 	aProposal.SignedVotes = append(aProposal.SignedVotes, types.SignedVote{
@@ -172,11 +276,123 @@ func (gov *Governmint) RunVoteTx(store base.KVStore, tx *types.VoteTx) tmsp.Resu
 		Signature: tx.Signature,
 	})
 	gov.SetActiveProposal(store, aProposal)
+	gov.lockVoteCredits(store, aProposal, tx.Vote)
 	return tmsp.NewResultOK(nil, "Vote added to ActiveProposal")
 }
 
+func (gov *Governmint) RunVoteCommitTx(store base.KVStore, tx *types.VoteCommitTx) tmsp.Result {
+	aProposal, res := gov.checkVoteCommitTx(store, tx)
+	if !res.IsOK() {
+		return res
+	}
+	// Good! Add a SignedVoteCommit
+	aProposal.SignedCommits = append(aProposal.SignedCommits, types.SignedVoteCommit{
+		Commit:    tx.Commit,
+		Signature: tx.Signature,
+	})
+	gov.SetActiveProposal(store, aProposal)
+	return tmsp.NewResultOK(nil, "Vote commit added to ActiveProposal")
+}
+
+func (gov *Governmint) RunVoteRevealTx(store base.KVStore, tx *types.VoteRevealTx) tmsp.Result {
+	aProposal, res := gov.checkVoteRevealTx(store, tx)
+	if !res.IsOK() {
+		return res
+	}
+	// Good! The revealed vote is counted like any other
+	aProposal.SignedVotes = append(aProposal.SignedVotes, types.SignedVote{
+		Vote:      tx.Vote,
+		Signature: tx.Signature,
+	})
+	gov.SetActiveProposal(store, aProposal)
+	gov.lockVoteCredits(store, aProposal, tx.Vote)
+	return tmsp.NewResultOK(nil, "Vote revealed")
+}
+
+func (gov *Governmint) RunVetoTx(store base.KVStore, tx *types.VetoTx) tmsp.Result {
+	cProposal, res := gov.checkVetoTx(store, tx)
+	if !res.IsOK() {
+		return res
+	}
+	// Good! The proposal will not be executed
+	cProposal.Vetoed = true
+	cProposal.Log = Fmt("Vetoed by %v", cProposal.VetoGroupID)
+	gov.SetClosedProposal(store, cProposal)
+	gov.removePendingExecution(store, cProposal.ExecuteHeight, cProposal.ID)
+	return tmsp.NewResultOK(nil, "Proposal vetoed")
+}
+
+func (gov *Governmint) RunEntityUpdateTx(store base.KVStore, tx *types.EntityUpdateTx) tmsp.Result {
+	entity, res := gov.checkEntityUpdateTx(store, tx)
+	if !res.IsOK() {
+		return res
+	}
+	// Good! Update the entity's profile
+	entity.Profile = tx.Profile
+	entity.Version = tx.NextVersion
+	gov.SetEntity(store, entity)
+	return tmsp.NewResultOK(nil, "Entity updated")
+}
+
+// Validates the tx against the current state without writing to store.
+// Suitable for the host application's CheckTx.
+// The ctx holds the coins sent with the tx, as for RunTx.
+func (gov *Governmint) CheckTx(store base.KVStore, ctx base.CallContext, txBytes []byte) tmsp.Result {
+	var tx types.Tx
+	err := wire.ReadBinaryBytes(txBytes, &tx)
+	if err != nil {
+		return tmsp.ErrEncodingError.SetLog(
+			Fmt("Error parsing Governmint tx bytes: %v", err.Error()))
+	}
+	return gov.CheckTxParsed(store, ctx, tx)
+}
+
+func (gov *Governmint) CheckTxParsed(store base.KVStore, ctx base.CallContext, tx types.Tx) (res tmsp.Result) {
+	defer recoverGovError(&res)
+	switch tx := tx.(type) {
+	case *types.ProposalTx:
+		if res := gov.checkProposalTx(store, ctx.Coins, tx); !res.IsOK() {
+			return res
+		}
+		return gov.checkProposalLimits(store, tx.EntityAddr, tx.Proposal.VoteGroupID)
+	case *types.VoteTx:
+		_, res := gov.checkVoteTx(store, tx)
+		return res
+	case *types.VoteCommitTx:
+		_, res := gov.checkVoteCommitTx(store, tx)
+		return res
+	case *types.VoteRevealTx:
+		_, res := gov.checkVoteRevealTx(store, tx)
+		return res
+	case *types.VetoTx:
+		_, res := gov.checkVetoTx(store, tx)
+		return res
+	case *types.EntityUpdateTx:
+		_, res := gov.checkEntityUpdateTx(store, tx)
+		return res
+	default:
+		return tmsp.NewError(types.CodeType_GovUnknownTx, "Unknown tx type")
+	}
+}
+
+// Implements basecoin.Plugin
+// Invalid validators are logged and leave the store unchanged,
+// see InitValidators.
 func (gov *Governmint) InitChain(store base.KVStore, validators []*tmsp.Validator) {
-	fmt.Println(common.Red(Fmt(">> B")))
+	if res := gov.InitValidators(store, validators); !res.IsOK() {
+		log.Error("Error in InitChain", "error", res.Log)
+	}
+}
+
+// Creates the validators group from the validator set.
+// The store is only written to if every validator is valid.
+func (gov *Governmint) InitValidators(store base.KVStore, validators []*tmsp.Validator) tmsp.Result {
+	return gov.runCached(store, func(cache base.KVStore) tmsp.Result {
+		return gov.initValidators(cache, validators)
+	})
+}
+
+func (gov *Governmint) initValidators(store base.KVStore, validators []*tmsp.Validator) tmsp.Result {
 	// Construct a group of entities for the validators.
 	vGroup := &types.Group{
 		ID:      types.ValidatorsGroupID,
@@ -186,7 +402,8 @@ func (gov *Governmint) InitChain(store base.KVStore, validators []*tmsp.Validato
 		var pubKey crypto.PubKey
 		err := wire.ReadBinaryBytes(validator.PubKey, &pubKey)
 		if err != nil {
-			PanicSanity("Error reading validator pubkey: " + err.Error())
+			return tmsp.ErrEncodingError.SetLog(
+				Fmt("Error decoding validator pubkey %X: %v", validator.PubKey, err.Error()))
 		}
 		// Create an entity with this validator
 		entity := &types.Entity{
@@ -202,193 +419,1284 @@ func (gov *Governmint) InitChain(store base.KVStore, validators []*tmsp.Validato
 		vGroup.Members = append(vGroup.Members, member)
 	}
 	// Save vGroup
-	fmt.Println(common.Red(Fmt(">> %v", vGroup)))
 	gov.SetGroup(store, vGroup)
+	return tmsp.OK
+}
+
+// Sets the time of the next block to begin, in Unix seconds from the block
+// header, so that every node sees the same time. Hosts call it before BeginBlock,
+// blocks begun without it have no known time.
+func (gov *Governmint) SetBlockTime(blockTime uint64) {
+	gov.blockTime = blockTime
 }
 
 func (gov *Governmint) BeginBlock(store base.KVStore, height uint64) {
+	defer logGovError("BeginBlock")
 	if govMeta, ok := gov.GetGovMeta(store); ok {
 		gov.GovMeta = govMeta
 	}
 	gov.GovMeta.Height = height
-	return
+	gov.GovMeta.BlockTime = gov.blockTime
+	gov.blockTime = 0
+	gov.executePendingProposals(store, height)
 }
 
 func (gov *Governmint) EndBlock(store base.KVStore, height uint64) []*tmsp.Validator {
+	gov.closeProposals(store, height)
 	gov.SetGovMeta(store, gov.GovMeta)
 	return nil // XXX Return changes to validator set
 }
 
-//----------------------------------------
-
-func (gov *Governmint) validateProposal(store base.KVStore, p types.Proposal, proposer *types.Entity) (res tmsp.Result) {
-	// Ensure that the proposal is unique
-	if _, exists := gov.GetActiveProposal(store, p.ID); exists {
-		return tmsp.NewError(tmsp.CodeType_GovDuplicateProposal,
-			Fmt("Proposal with id %v already exists", p.ID))
-	}
-	// Ensure that the voting group exists
-	voteGroup, ok := gov.GetGroup(store, p.VoteGroupID)
-	if !ok {
-		return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
-			Fmt("Vote group with id %v doesn't exist", p.VoteGroupID))
-	}
-	// Ensure that the proposer belongs to the voting group
-	if !isMemberOf(voteGroup, proposer.Addr) {
-		return tmsp.NewError(tmsp.CodeType_Unauthorized,
-			Fmt("Proposer %X is not member of %v", proposer.Addr, voteGroup.ID))
+// Returns the binary encoded object in the result data.
+func (gov *Governmint) Query(store base.KVStore, queryBytes []byte) tmsp.Result {
+	var query types.Query
+	err := wire.ReadBinaryBytes(queryBytes, &query)
+	if err != nil {
+		return tmsp.ErrEncodingError.SetLog(
+			Fmt("Error parsing Governmint query bytes: %v", err.Error()))
 	}
-	// Type dependent checks
-	switch pInfo := p.Info.(type) {
-	case *types.GroupCreateProposalInfo:
-		// Ensure that the group ID is not taken
-		if _, exists := gov.GetGroup(store, pInfo.NewGroupID); exists {
-			return tmsp.NewError(tmsp.CodeType_GovDuplicateGroup,
-				Fmt("Group with id %v already exists", pInfo.NewGroupID))
-		}
-		// Ensure that the member entities are unique
-		if ok, dupe := validateUniqueMembers(pInfo.Members); !ok {
-			return tmsp.NewError(tmsp.CodeType_GovDuplicateMember,
-				Fmt("Duplicate member %v", dupe))
-		}
-		// Ensure that the member voting powers are reasonable
-		for _, member := range pInfo.Members {
-			if member.VotingPower == 0 {
-				return tmsp.NewError(tmsp.CodeType_GovInvalidVotingPower,
-					Fmt("Member cannot have 0 voting power"))
-			}
-			if member.VotingPower > MaxVotingPower {
-				return tmsp.NewError(tmsp.CodeType_GovInvalidVotingPower,
-					Fmt("Member voting power too large"))
-			}
-		}
-		// Ensure that all the entities exist
-		entityAddrs := entityAddrsFromMembers(pInfo.Members)
-		_, unknownEntityAddr := gov.loadEntities(store, entityAddrs)
-		if unknownEntityAddr != nil {
+	return gov.QueryParsed(store, query)
+}
+
+func (gov *Governmint) QueryParsed(store base.KVStore, query types.Query) (res tmsp.Result) {
+	defer recoverGovError(&res)
+	switch query := query.(type) {
+	case *types.EntityQuery:
+		entity, ok := gov.GetEntity(store, query.EntityAddr)
+		if !ok {
 			return tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
-				Fmt("Group creation with unknown entity %X", unknownEntityAddr))
+				Fmt("Entity %X unknown", query.EntityAddr))
 		}
-	case *types.GroupUpdateProposalInfo:
-		// Ensure that the update group exists
-		updateGroup, ok := gov.GetGroup(store, pInfo.UpdateGroupID)
+		return tmsp.NewResultOK(wire.BinaryBytes(*entity), "")
+	case *types.GroupQuery:
+		group, ok := gov.GetGroup(store, query.GroupID)
 		if !ok {
 			return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
-				Fmt("Group with id %v doesn't exist", pInfo.UpdateGroupID))
+				Fmt("Group with id %v doesn't exist", query.GroupID))
 		}
-		// Ensure that the update group's parent is the voting group
-		// XXX Admin check?
-		if updateGroup.ParentID != voteGroup.ID {
-			return tmsp.NewError(tmsp.CodeType_Unauthorized,
-				Fmt("Group %v cannot update %v", voteGroup.ID, updateGroup.ID))
+		return tmsp.NewResultOK(wire.BinaryBytes(*group), "")
+	case *types.ActiveProposalQuery:
+		aProposal, ok := gov.GetActiveProposal(store, query.ProposalID)
+		if !ok {
+			return tmsp.NewError(tmsp.CodeType_GovUnknownProposal,
+				Fmt("Unknown proposal %v", query.ProposalID))
 		}
-		// Ensure that the member entities are unique
-		if ok, dupe := validateUniqueMembers(pInfo.ChangedMembers); !ok {
-			return tmsp.NewError(tmsp.CodeType_GovDuplicateMember,
-				Fmt("Duplicate member %v", dupe))
+		return tmsp.NewResultOK(wire.BinaryBytes(*aProposal), "")
+	case *types.GovMetaQuery:
+		return tmsp.NewResultOK(wire.BinaryBytes(*gov.GovMeta), "")
+	case *types.ClosedProposalQuery:
+		cProposal, ok := gov.GetClosedProposal(store, query.ProposalID)
+		if !ok {
+			return tmsp.NewError(tmsp.CodeType_GovUnknownProposal,
+				Fmt("Unknown closed proposal %v", query.ProposalID))
 		}
-		// Ensure that the member voting powers are reasonable
-		for _, member := range pInfo.ChangedMembers {
-			if member.VotingPower == 0 {
-				// This is fine, we're removing members.
+		return tmsp.NewResultOK(wire.BinaryBytes(*cProposal), "")
+	case *types.VoteCreditsQuery:
+		credits := gov.getVoteCredits(store, query.GroupID, query.EntityAddr)
+		return tmsp.NewResultOK(wire.BinaryBytes(*credits), "")
+	case *types.PendingExecutionsQuery:
+		pending := []types.PendingExecution{}
+		for _, height := range gov.GetPendingExecutionHeights(store) {
+			for _, id := range gov.GetPendingExecutions(store, height) {
+				pending = append(pending, types.PendingExecution{Height: height, ProposalID: id})
 			}
-			if member.VotingPower > MaxVotingPower {
-				return tmsp.NewError(tmsp.CodeType_GovInvalidVotingPower,
-					Fmt("Member voting power too large"))
-			}
-		}
-		// Ensure that all the entities exist
-		entityAddrs := entityAddrsFromMembers(pInfo.ChangedMembers)
-		_, unknownEntityAddr := gov.loadEntities(store, entityAddrs)
-		if unknownEntityAddr != nil {
-			return tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
-				Fmt("Group creation with unknown entity %X", unknownEntityAddr))
-		}
-	case *types.TextProposalInfo:
-		// TODO text string validation, e.g. max length
-	case *types.UpgradeProposalInfo:
-		// Ensure that the group is admin.
-		if voteGroup.ID != types.AdminGroupID {
-			return tmsp.NewError(tmsp.CodeType_Unauthorized,
-				Fmt("Upgrade proposals must be voted on by admin group"))
 		}
-		// Ensure that the number of modules is > 0.
-		if len(pInfo.Modules) == 0 {
-			return tmsp.NewError(tmsp.CodeType_EncodingError,
-				Fmt("Software upgrade requires > 0 modules"))
+		return tmsp.NewResultOK(wire.BinaryBytes(pending), "")
+	case *types.DepositSettlementsQuery:
+		settlements := gov.GetDepositSettlements(store, query.Height)
+		return tmsp.NewResultOK(wire.BinaryBytes(settlements), "")
+	case *types.MemberExpirationsQuery:
+		height := gov.GovMeta.Height
+		expirations := []types.MemberExpiration{}
+		for _, id := range gov.GetGroupIDs(store) {
+			group, ok := gov.GetGroup(store, id)
+			if !ok {
+				continue
+			}
+			for _, member := range group.Members {
+				if member.ExpiresHeight == 0 || member.ExpiredAt(height) {
+					continue
+				}
+				if query.Within != 0 && member.ExpiresHeight > height+query.Within {
+					continue
+				}
+				expirations = append(expirations, types.MemberExpiration{
+					ExpiresHeight: member.ExpiresHeight,
+					GroupID:       group.ID,
+					EntityAddr:    member.EntityAddr,
+				})
+			}
 		}
+		// Soonest first, groups are already in order of ID
+		sort.Stable(memberExpirations(expirations))
+		return tmsp.NewResultOK(wire.BinaryBytes(expirations), "")
+	default:
+		return tmsp.NewError(types.CodeType_GovUnknownQuery, "Unknown query type")
 	}
-	return tmsp.NewResultOK(nil, "")
 }
 
-// Returns (true, "") if members are unique
-// Returns (false, <duplicateEntityAddr>) if members are not unique
-// NOTE: zero members is fine.
-func validateUniqueMembers(members []types.Member) (bool, []byte) {
-	entityAddrs := map[string]struct{}{}
-	for _, member := range members {
-		if _, exists := entityAddrs[string(member.EntityAddr)]; exists {
-			return false, member.EntityAddr
-		}
-		entityAddrs[string(member.EntityAddr)] = struct{}{}
-	}
-	return true, nil
-}
+//----------------------------------------
 
-func entityAddrsFromMembers(members []types.Member) [][]byte {
-	entityAddrs := make([][]byte, len(members))
-	for i, member := range members {
-		entityAddrs[i] = member.EntityAddr
+// Does not write to store.
+func (gov *Governmint) checkProposalTx(store base.KVStore, deposit base.Coins, tx *types.ProposalTx) tmsp.Result {
+	// Ensure that proposer exists
+	entity, ok := gov.GetEntity(store, tx.EntityAddr)
+	if !ok {
+		return tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
+			Fmt("Entity %X unknown", tx.EntityAddr))
 	}
-	return entityAddrs
+	// Ensure signature is valid
+	signBytes := tx.SignBytes(gov.GovMeta.ChainID)
+	if !entity.PubKey.VerifyBytes(signBytes, tx.Signature) {
+		return tmsp.NewError(tmsp.CodeType_Unauthorized,
+			Fmt("Invalid signature"))
+	}
+	// Ensure that the proposal hasn't been proposed before, e.g. by replaying the tx
+	if id, exists := gov.GetProposalHashID(store, proposalHash(gov.GovMeta.ChainID, tx)); exists {
+		return tmsp.NewError(tmsp.CodeType_GovDuplicateProposal,
+			Fmt("Proposal already exists with id %v", id))
+	}
+	// Ensure that the proposal is valid
+	return gov.validateProposal(store, tx.Proposal, entity, deposit)
 }
 
-// Returns (nil, <firstUnknownEntityAddr>) if any unknown
-func (gov *Governmint) loadEntities(store base.KVStore, entityAddrs [][]byte) ([]*types.Entity, []byte) {
-	entities := make([]*types.Entity, len(entityAddrs))
-	for i, entityAddr := range entityAddrs {
-		entity, ok := gov.GetEntity(store, entityAddr)
+// Limits how many proposals the proposer, and the voting group,
+// may have open at a time, and how soon after a rejection the proposer may propose.
+// Does not write to store.
+func (gov *Governmint) checkProposalLimits(store base.KVStore, proposer []byte, voteGroupID string) tmsp.Result {
+	params := gov.govParams(store)
+	if rejectionHeight, ok := gov.GetRejectionHeight(store, proposer); ok &&
+		gov.GovMeta.Height < rejectionHeight+params.RejectionCooldown {
+		return tmsp.NewError(types.CodeType_GovRateLimited,
+			Fmt("Proposer %X cannot propose until height %v",
+				proposer, rejectionHeight+params.RejectionCooldown))
+	}
+	var byProposer, byGroup uint64
+	for _, id := range gov.GetActiveProposalIDs(store) {
+		aProposal, ok := gov.GetActiveProposal(store, id)
 		if !ok {
-			return nil, entityAddr
+			continue
 		}
-		entities[i] = entity
-	}
-	return entities, nil
-}
-
-func isMemberOf(group *types.Group, entityAddr []byte) bool {
-	for _, member := range group.Members {
-		if bytes.Equal(member.EntityAddr, entityAddr) {
-			return true
+		if bytes.Equal(aProposal.Proposer, proposer) {
+			byProposer++
+		}
+		if aProposal.VoteGroupID == voteGroupID {
+			byGroup++
 		}
 	}
-	return false
+	if params.MaxActiveProposalsPerProposer != 0 && byProposer >= params.MaxActiveProposalsPerProposer {
+		return tmsp.NewError(types.CodeType_GovRateLimited,
+			Fmt("Proposer %X has too many active proposals", proposer))
+	}
+	if params.MaxActiveProposalsPerGroup != 0 && byGroup >= params.MaxActiveProposalsPerGroup {
+		return tmsp.NewError(types.CodeType_GovRateLimited,
+			Fmt("Group %v has too many active proposals", voteGroupID))
+	}
+	return tmsp.OK
 }
 
-func hasVoted(aProposal *types.ActiveProposal, entityAddr []byte) (bool, int) {
-	for i, sVote := range aProposal.SignedVotes {
-		if bytes.Equal(sVote.Vote.EntityAddr, entityAddr) {
-			return true, i
-		}
+// Does not write to store.
+// Returns the proposal being voted on if the vote is valid.
+func (gov *Governmint) checkVoteTx(store base.KVStore, tx *types.VoteTx) (*types.ActiveProposal, tmsp.Result) {
+	aProposal, res := gov.checkVoter(store, tx.Vote.EntityAddr, tx.Vote.ProposalID, tx.Vote.GroupID,
+		tx.Vote.Height, tx.SignBytes(gov.GovMeta.ChainID), tx.Signature)
+	if !res.IsOK() {
+		return nil, res
 	}
-	return false, -1
+	// Ensure that the proposal takes plain votes
+	if aProposal.SecretBallot {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Proposal %v is a secret ballot, votes must be committed", aProposal.ID))
+	}
+	// Ensure that the vote's height matches the proposal's range
+	// Conviction proposals and proposals with an EndTime have no EndHeight
+	conviction := isConvictionProposal(&aProposal.Proposal)
+	timed := aProposal.EndTime != 0
+	if !(aProposal.StartHeight <= tx.Vote.Height &&
+		(conviction || timed || tx.Vote.Height <= aProposal.EndHeight)) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Vote height is invalid"))
+	}
+	// Ensure that the block's time is within the proposal's voting time
+	if timed && !(aProposal.StartTime <= gov.GovMeta.BlockTime &&
+		gov.GovMeta.BlockTime <= aProposal.EndTime) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Voting is closed at block time %v", gov.GovMeta.BlockTime))
+	}
+	// Ensure that the vote's value is valid
+	if !isValidVoteValue(aProposal, tx.Vote.Value) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Invalid vote value %v", tx.Vote.Value))
+	}
+	// Ensure that conviction votes are recent, since they accrue from their height
+	if conviction && tx.Vote.Height+1 < gov.GovMeta.Height {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Conviction vote height is too old"))
+	}
+	// Ensure that the voter hasn't already voted
+	// Conviction votes are standing votes that may be replaced
+	groupID := voteGroupID(aProposal, tx.Vote)
+	if exists, _ := hasVoted(aProposal, groupID, tx.Vote.EntityAddr); exists && !conviction {
+		return nil, tmsp.NewError(tmsp.CodeType_GovDuplicateVote,
+			Fmt("Voter %X already voted in %v", tx.Vote.EntityAddr, groupID))
+	}
+	// Ensure that the voter has the credits for the vote
+	if res := gov.checkVoteCredits(store, aProposal, tx.Vote); !res.IsOK() {
+		return nil, res
+	}
+	return aProposal, tmsp.NewResultOK(nil, "")
 }
 
-//----------------------------------------
-
-// Get some object, or panic.
-// objPtr: pointer to the object to populate, if value exists for key
-// Use the return value, so nil can be returned for keys with no value.
-func (gov *Governmint) getObject(store base.KVStore, key []byte, objPtr interface{}) interface{} {
-	valueBytes := store.Get(key)
-	if len(valueBytes) == 0 {
-		return nil // NOTE must use return value
+// Does not write to store.
+func (gov *Governmint) checkVoteCommitTx(store base.KVStore, tx *types.VoteCommitTx) (*types.ActiveProposal, tmsp.Result) {
+	aProposal, res := gov.checkVoter(store, tx.Commit.EntityAddr, tx.Commit.ProposalID, "",
+		tx.Commit.Height, tx.SignBytes(gov.GovMeta.ChainID), tx.Signature)
+	if !res.IsOK() {
+		return nil, res
 	}
-	err := wire.ReadBinaryBytes(valueBytes, objPtr)
-	if err != nil {
-		panic("Error parsing obj: " + err.Error())
+	// Ensure that the proposal is a secret ballot
+	if !aProposal.SecretBallot {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Proposal %v is not a secret ballot", aProposal.ID))
 	}
-	return objPtr
+	// Ensure that the commit's height matches the proposal's range
+	if !(aProposal.StartHeight <= tx.Commit.Height &&
+		tx.Commit.Height <= aProposal.EndHeight) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Vote commit height is invalid"))
+	}
+	// Ensure that the voter hasn't already committed
+	if exists, _ := hasCommitted(aProposal, tx.Commit.EntityAddr); exists {
+		return nil, tmsp.NewError(tmsp.CodeType_GovDuplicateVote,
+			Fmt("Voter %X already committed", tx.Commit.EntityAddr))
+	}
+	return aProposal, tmsp.NewResultOK(nil, "")
+}
+
+// Does not write to store.
+func (gov *Governmint) checkVoteRevealTx(store base.KVStore, tx *types.VoteRevealTx) (*types.ActiveProposal, tmsp.Result) {
+	aProposal, res := gov.checkVoter(store, tx.Vote.EntityAddr, tx.Vote.ProposalID, tx.Vote.GroupID,
+		tx.Vote.Height, tx.SignBytes(gov.GovMeta.ChainID), tx.Signature)
+	if !res.IsOK() {
+		return nil, res
+	}
+	// Ensure that the proposal is a secret ballot
+	if !aProposal.SecretBallot {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Proposal %v is not a secret ballot", aProposal.ID))
+	}
+	// Ensure that the reveal's height is within the reveal period
+	if !(aProposal.EndHeight < tx.Vote.Height &&
+		tx.Vote.Height <= gov.closeHeight(store, aProposal)) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Vote reveal height is invalid"))
+	}
+	// Ensure that the vote's value is valid
+	if !isValidVoteValue(aProposal, tx.Vote.Value) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Invalid vote value %v", tx.Vote.Value))
+	}
+	// Ensure that the voter hasn't already revealed
+	if exists, _ := hasVoted(aProposal, aProposal.VoteGroupID, tx.Vote.EntityAddr); exists {
+		return nil, tmsp.NewError(tmsp.CodeType_GovDuplicateVote,
+			Fmt("Voter %X already revealed", tx.Vote.EntityAddr))
+	}
+	// Ensure that the vote matches the voter's commit
+	exists, i := hasCommitted(aProposal, tx.Vote.EntityAddr)
+	if !exists {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Voter %X did not commit", tx.Vote.EntityAddr))
+	}
+	commitHash := aProposal.SignedCommits[i].Commit.Hash
+	revealHash := types.VoteCommitHash(gov.GovMeta.ChainID, aProposal.ID,
+		voteGroupID(aProposal, tx.Vote), tx.Vote.EntityAddr, tx.Vote.Value, tx.Vote.Credits, tx.Salt)
+	if !bytes.Equal(commitHash, revealHash) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Vote reveal doesn't match commit"))
+	}
+	// Ensure that the voter has the credits for the vote
+	if res := gov.checkVoteCredits(store, aProposal, tx.Vote); !res.IsOK() {
+		return nil, res
+	}
+	return aProposal, tmsp.NewResultOK(nil, "")
+}
+
+// Does not write to store.
+// Returns the closed proposal being vetoed if the veto is valid.
+func (gov *Governmint) checkVetoTx(store base.KVStore, tx *types.VetoTx) (*types.ClosedProposal, tmsp.Result) {
+	// Ensure that the proposal is awaiting execution
+	cProposal, ok := gov.GetClosedProposal(store, tx.ProposalID)
+	if !ok {
+		return nil, tmsp.NewError(tmsp.CodeType_GovUnknownProposal,
+			Fmt("Unknown closed proposal %v", tx.ProposalID))
+	}
+	if cProposal.VetoGroupID == "" || cProposal.Vetoed || cProposal.Canceled ||
+		cProposal.ExecuteHeight <= gov.GovMeta.Height {
+		return nil, tmsp.NewError(types.CodeType_GovInvalidVeto,
+			Fmt("Proposal %v cannot be vetoed", cProposal.ID))
+	}
+	// Fetch the veto group
+	vetoGroup, ok := gov.getVotingGroup(store, cProposal.VetoGroupID)
+	if !ok {
+		return nil, tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+			Fmt("Veto group with id %v doesn't exist", cProposal.VetoGroupID))
+	}
+	// Ensure that each signer is a distinct member with a valid signature
+	signBytes := tx.SignBytes(gov.GovMeta.ChainID)
+	signers := map[string]struct{}{}
+	var power uint64
+	for _, vetoSig := range tx.Signatures {
+		if _, exists := signers[string(vetoSig.EntityAddr)]; exists {
+			return nil, tmsp.NewError(types.CodeType_GovInvalidVeto,
+				Fmt("Duplicate veto signer %X", vetoSig.EntityAddr))
+		}
+		signers[string(vetoSig.EntityAddr)] = struct{}{}
+		entity, ok := gov.GetEntity(store, vetoSig.EntityAddr)
+		if !ok {
+			return nil, tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
+				Fmt("Entity %X unknown", vetoSig.EntityAddr))
+		}
+		if !entity.PubKey.VerifyBytes(signBytes, vetoSig.Signature) {
+			return nil, tmsp.NewError(tmsp.CodeType_Unauthorized,
+				Fmt("Invalid signature"))
+		}
+		if !isMemberOf(vetoGroup, entity.Addr) {
+			return nil, tmsp.NewError(tmsp.CodeType_GovInvalidMember,
+				Fmt("Signer %X not a member of %v", entity.Addr, vetoGroup.ID))
+		}
+		power += votingPowerOf(vetoGroup, entity.Addr)
+	}
+	// Ensure that the signers pass the veto group's threshold
+	if !exceedsFraction(power, totalVotingPower(vetoGroup), vetoGroup.Policy.Threshold()) {
+		return nil, tmsp.NewError(tmsp.CodeType_Unauthorized,
+			Fmt("Insufficient voting power to veto"))
+	}
+	return cProposal, tmsp.NewResultOK(nil, "")
+}
+
+// Does not write to store.
+// Returns the entity being updated if the update is valid.
+func (gov *Governmint) checkEntityUpdateTx(store base.KVStore, tx *types.EntityUpdateTx) (*types.Entity, tmsp.Result) {
+	// Ensure that the entity exists
+	entity, ok := gov.GetEntity(store, tx.EntityAddr)
+	if !ok {
+		return nil, tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
+			Fmt("Entity %X unknown", tx.EntityAddr))
+	}
+	// Ensure that the entity signed the update
+	if !entity.PubKey.VerifyBytes(tx.SignBytes(gov.GovMeta.ChainID), tx.Signature) {
+		return nil, tmsp.NewError(tmsp.CodeType_Unauthorized,
+			Fmt("Invalid signature"))
+	}
+	// Ensure that the entity hasn't changed since the update was signed,
+	// so that it can't be replayed
+	if tx.NextVersion != entity.Version+1 {
+		return nil, tmsp.NewError(types.CodeType_GovInvalidEntityVersion,
+			Fmt("Entity %X is at version %v, expected next version %v",
+				entity.Addr, entity.Version, tx.NextVersion))
+	}
+	// Ensure that the profile is valid
+	if res := validateEntityProfile(tx.Profile, entity.PubKey); !res.IsOK() {
+		return nil, res
+	}
+	return entity, tmsp.NewResultOK(nil, "")
+}
+
+// Does not write to store.
+// Returns the IDs of the groups to delete, the group first
+// and then its descendants if cascading, in a deterministic order.
+func (gov *Governmint) checkGroupDelete(store base.KVStore, voteGroupID string,
+	pInfo *types.GroupDeleteProposalInfo) ([]string, tmsp.Result) {
+	// Ensure that the delete group exists
+	deleteGroup, ok := gov.GetGroup(store, pInfo.DeleteGroupID)
+	if !ok {
+		return nil, tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+			Fmt("Group with id %v doesn't exist", pInfo.DeleteGroupID))
+	}
+	// Ensure that the delete group's parent is the voting group
+	if deleteGroup.ParentID != voteGroupID {
+		return nil, tmsp.NewError(tmsp.CodeType_Unauthorized,
+			Fmt("Group %v cannot delete %v", voteGroupID, deleteGroup.ID))
+	}
+	groupIDs := gov.GetGroupIDs(store)
+	deleteIDs := []string{deleteGroup.ID}
+	if pInfo.Cascade {
+		for i := 0; i < len(deleteIDs); i++ {
+			for _, id := range groupIDs {
+				if group, ok := gov.GetGroup(store, id); ok && group.ParentID == deleteIDs[i] {
+					deleteIDs = append(deleteIDs, id)
+				}
+			}
+		}
+	}
+	// Ensure that no remaining group proposes through a deleted group
+	for _, id := range groupIDs {
+		group, ok := gov.GetGroup(store, id)
+		if !ok || containsString(deleteIDs, id) {
+			continue
+		}
+		if containsString(deleteIDs, group.Policy.ProposerGroupID) {
+			return nil, tmsp.NewError(tmsp.CodeType_Unauthorized,
+				Fmt("Group %v is the proposer group of %v", group.Policy.ProposerGroupID, group.ID))
+		}
+	}
+	return deleteIDs, tmsp.NewResultOK(nil, "")
+}
+
+// Checks common to all votes.
+// Does not write to store.
+// Returns the proposal being voted on.
+// The empty groupID means the proposal's VoteGroupID.
+func (gov *Governmint) checkVoter(store base.KVStore, entityAddr []byte, proposalID string, groupID string,
+	height uint64, signBytes []byte, sig crypto.Signature) (*types.ActiveProposal, tmsp.Result) {
+	// Ensure that voter exists
+	entity, ok := gov.GetEntity(store, entityAddr)
+	if !ok {
+		return nil, tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
+			Fmt("Entity %X unknown", entityAddr))
+	}
+	// Ensure signature is valid
+	if !entity.PubKey.VerifyBytes(signBytes, sig) {
+		return nil, tmsp.NewError(tmsp.CodeType_Unauthorized,
+			Fmt("Invalid signature"))
+	}
+	// Ensure that the proposal exists
+	aProposal, ok := gov.GetActiveProposal(store, proposalID)
+	if !ok {
+		return nil, tmsp.NewError(tmsp.CodeType_GovUnknownProposal,
+			Fmt("Unknown proposal %v", proposalID))
+	}
+	// Ensure that the vote's height is <= current height
+	if !(height <= gov.GovMeta.Height) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Vote height is invalid"))
+	}
+	// Ensure that the group votes on the proposal
+	if groupID == "" {
+		groupID = aProposal.VoteGroupID
+	}
+	if !isVoteGroupOf(&aProposal.Proposal, groupID) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Group %v doesn't vote on proposal %v", groupID, aProposal.ID))
+	}
+	// Fetch the voting group
+	voteGroup, ok := gov.getVotingGroup(store, groupID)
+	if !ok {
+		return nil, tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+			Fmt("Vote group with id %v doesn't exist", groupID))
+	}
+	// Ensure that the voter belongs to the voting group
+	if !isMemberOf(voteGroup, entity.Addr) {
+		return nil, tmsp.NewError(tmsp.CodeType_GovInvalidMember,
+			Fmt("Voter %X not a member of %v", entity.Addr, voteGroup.ID))
+	}
+	return aProposal, tmsp.NewResultOK(nil, "")
+}
+
+//----------------------------------------
+
+func (gov *Governmint) validateProposal(store base.KVStore, p types.Proposal,
+	proposer *types.Entity, deposit base.Coins) (res tmsp.Result) {
+	// Ensure that the proposal leaves the ID to governmint
+	if p.ID != "" {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Proposal id is assigned by governmint and must be empty"))
+	}
+	// Ensure that the voting group exists
+	voteGroup, ok := gov.getVotingGroup(store, p.VoteGroupID)
+	if !ok {
+		return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+			Fmt("Vote group with id %v doesn't exist", p.VoteGroupID))
+	}
+	// Ensure that the proposer may propose to the voting group
+	if res := gov.checkProposer(store, voteGroup, proposer, deposit); !res.IsOK() {
+		return res
+	}
+	// Ensure that the proposal's metadata is within limits
+	if res := validateProposalMetadata(p); !res.IsOK() {
+		return res
+	}
+	// Ensure that the voting period is reasonable
+	if res := gov.validateVotingPeriod(store, p); !res.IsOK() {
+		return res
+	}
+	// Ensure that the voting mode is valid
+	if !isValidVotingMode(p.VotingMode) {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Unknown voting mode %v", p.VotingMode))
+	}
+	// Ensure that lazy consensus is only used for yes/no proposals
+	// voted on by groups that allow it
+	if p.LazyConsensus {
+		switch p.Info.(type) {
+		case *types.ChoiceProposalInfo, *types.ConvictionProposalInfo:
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Lazy consensus requires a yes/no proposal"))
+		}
+		if !voteGroup.Policy.AllowLazyConsensus {
+			return tmsp.NewError(tmsp.CodeType_Unauthorized,
+				Fmt("Vote group %v doesn't allow lazy consensus", voteGroup.ID))
+		}
+	}
+	// Ensure that the co-voting groups exist and are distinct
+	if len(p.CoVoteGroups) > 0 {
+		switch p.Info.(type) {
+		case *types.ChoiceProposalInfo, *types.ConvictionProposalInfo:
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Co-voting groups require a yes/no proposal"))
+		}
+		if p.SecretBallot {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Co-voting groups cannot be used with secret ballots"))
+		}
+		groupIDs := []string{p.VoteGroupID}
+		for _, coVoteGroup := range p.CoVoteGroups {
+			if indexOfString(groupIDs, coVoteGroup.GroupID) >= 0 {
+				return tmsp.NewError(tmsp.CodeType_GovDuplicateGroup,
+					Fmt("Duplicate vote group %v", coVoteGroup.GroupID))
+			}
+			groupIDs = append(groupIDs, coVoteGroup.GroupID)
+			coGroup, ok := gov.GetGroup(store, coVoteGroup.GroupID)
+			if !ok {
+				return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+					Fmt("Vote group with id %v doesn't exist", coVoteGroup.GroupID))
+			}
+			if p.LazyConsensus && !coGroup.Policy.AllowLazyConsensus {
+				return tmsp.NewError(tmsp.CodeType_Unauthorized,
+					Fmt("Vote group %v doesn't allow lazy consensus", coGroup.ID))
+			}
+			if res := validateThreshold("Vote", coVoteGroup.Threshold); !res.IsOK() {
+				return res
+			}
+		}
+	}
+	// Ensure that emergency proposals are voted by admin alone on a yes/no question
+	if p.Emergency {
+		if p.VoteGroupID != types.AdminGroupID || len(p.CoVoteGroups) > 0 {
+			return tmsp.NewError(tmsp.CodeType_Unauthorized,
+				Fmt("Emergency proposals must be voted on by admin group alone"))
+		}
+		switch p.Info.(type) {
+		case *types.ChoiceProposalInfo, *types.ConvictionProposalInfo:
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Emergency proposals require a yes/no proposal"))
+		}
+		if p.LazyConsensus {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Emergency proposals cannot use lazy consensus"))
+		}
+	}
+	// Type dependent checks
+	switch pInfo := p.Info.(type) {
+	case *types.GroupCreateProposalInfo:
+		// Ensure that the group ID is not taken
+		if _, exists := gov.GetGroup(store, pInfo.NewGroupID); exists {
+			return tmsp.NewError(tmsp.CodeType_GovDuplicateGroup,
+				Fmt("Group with id %v already exists", pInfo.NewGroupID))
+		}
+		// Ensure that the members are valid
+		if res := gov.validateMembers(store, pInfo.Members, false); !res.IsOK() {
+			return res
+		}
+		// Ensure that the charter is valid
+		if res := validateGroupCharter(pInfo.Charter); !res.IsOK() {
+			return res
+		}
+	case *types.GroupUpdateProposalInfo:
+		// Ensure that the update group exists
+		updateGroup, ok := gov.GetGroup(store, pInfo.UpdateGroupID)
+		if !ok {
+			return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+				Fmt("Group with id %v doesn't exist", pInfo.UpdateGroupID))
+		}
+		// Ensure that the update group's parent is the voting group
+		// XXX Admin check?
+		if updateGroup.ParentID != voteGroup.ID {
+			return tmsp.NewError(tmsp.CodeType_Unauthorized,
+				Fmt("Group %v cannot update %v", voteGroup.ID, updateGroup.ID))
+		}
+		// Ensure that the changed members are valid, 0 VotingPower removes
+		if res := gov.validateMembers(store, pInfo.ChangedMembers, true); !res.IsOK() {
+			return res
+		}
+	case *types.GroupCharterProposalInfo:
+		// Ensure that the update group exists
+		updateGroup, ok := gov.GetGroup(store, pInfo.UpdateGroupID)
+		if !ok {
+			return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+				Fmt("Group with id %v doesn't exist", pInfo.UpdateGroupID))
+		}
+		// Ensure that the update group's parent is the voting group
+		if updateGroup.ParentID != voteGroup.ID {
+			return tmsp.NewError(tmsp.CodeType_Unauthorized,
+				Fmt("Group %v cannot update the charter of %v", voteGroup.ID, updateGroup.ID))
+		}
+		// Ensure that the charter is valid
+		if res := validateGroupCharter(pInfo.Charter); !res.IsOK() {
+			return res
+		}
+	case *types.GroupDeleteProposalInfo:
+		// Ensure that the group can be deleted by the voting group
+		if _, res := gov.checkGroupDelete(store, voteGroup.ID, pInfo); !res.IsOK() {
+			return res
+		}
+	case *types.TextProposalInfo:
+		// Ensure that the text is reasonable
+		if len(pInfo.Text) > MaxTextLength {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Text cannot be longer than %v bytes", MaxTextLength))
+		}
+	case *types.UpgradeProposalInfo:
+		// Ensure that the group is admin.
+		if voteGroup.ID != types.AdminGroupID {
+			return tmsp.NewError(tmsp.CodeType_Unauthorized,
+				Fmt("Upgrade proposals must be voted on by admin group"))
+		}
+		// Ensure that the number of modules is > 0.
+		if len(pInfo.Modules) == 0 {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Software upgrade requires > 0 modules"))
+		}
+	case *types.ConvictionProposalInfo:
+		// Ensure that the proposal runs until it has enough conviction
+		if p.EndHeight != 0 {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Conviction proposals cannot have an end height"))
+		}
+		if p.SecretBallot {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Conviction proposals cannot be secret ballots"))
+		}
+		// Ensure that funds are requested for someone
+		if len(pInfo.Recipient) == 0 || pInfo.Amount == 0 {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Conviction proposal requires a recipient and an amount"))
+		}
+	case *types.ChoiceProposalInfo:
+		// Ensure that the method is known
+		if pInfo.Method != types.ChoiceMethodPlurality &&
+			pInfo.Method != types.ChoiceMethodInstantRunoff {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Unknown choice method %v", pInfo.Method))
+		}
+		// Ensure that there is something to choose from
+		if len(pInfo.Options) < 2 {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Choice proposal requires >= 2 options"))
+		}
+		// Ensure that the options are unique and can be ranked
+		if ok, dupe := validateUniqueStrings(pInfo.Options); !ok {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Duplicate option %v", dupe))
+		}
+		for _, option := range pInfo.Options {
+			if option == "" || option == types.VoteValueAbstain ||
+				strings.Contains(option, types.RankingSeparator) {
+				return tmsp.NewError(tmsp.CodeType_EncodingError,
+					Fmt("Invalid option %v", option))
+			}
+		}
+	}
+	return tmsp.NewResultOK(nil, "")
+}
+
+func validateEntityProfile(profile types.EntityProfile, pubKey crypto.PubKey) tmsp.Result {
+	if len(profile.Name) > MaxEntityNameLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Name cannot be longer than %v bytes", MaxEntityNameLength))
+	}
+	if len(profile.Contact) > MaxEntityContactLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Contact cannot be longer than %v bytes", MaxEntityContactLength))
+	}
+	if len(profile.Website) > MaxEntityWebsiteLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Website cannot be longer than %v bytes", MaxEntityWebsiteLength))
+	}
+	switch profile.KeyType {
+	case "", types.KeyTypeEd25519, types.KeyTypeSecp256k1:
+	default:
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Unknown key type %v", profile.KeyType))
+	}
+	// Ensure that the key type describes the entity's pubkey
+	if profile.KeyType != "" && profile.KeyType != pubKeyType(pubKey) {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Key type %v doesn't match the entity's pubkey", profile.KeyType))
+	}
+	return tmsp.OK
+}
+
+// Returns the KeyType* of the pubkey, or "" if it is of another type.
+func pubKeyType(pubKey crypto.PubKey) string {
+	switch pubKey.(type) {
+	case crypto.PubKeyEd25519:
+		return types.KeyTypeEd25519
+	case crypto.PubKeySecp256k1:
+		return types.KeyTypeSecp256k1
+	}
+	return ""
+}
+
+func validateGroupCharter(charter types.GroupCharter) tmsp.Result {
+	if len(charter.Name) > MaxGroupNameLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Group name cannot be longer than %v bytes", MaxGroupNameLength))
+	}
+	if len(charter.Description) > MaxDescriptionLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Group description cannot be longer than %v bytes", MaxDescriptionLength))
+	}
+	if len(charter.CharterHash) > MaxContentHashLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("CharterHash cannot be longer than %v bytes", MaxContentHashLength))
+	}
+	return tmsp.OK
+}
+
+func validateProposalMetadata(p types.Proposal) tmsp.Result {
+	if len(p.Title) > MaxTitleLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Title cannot be longer than %v bytes", MaxTitleLength))
+	}
+	if len(p.Description) > MaxDescriptionLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Description cannot be longer than %v bytes", MaxDescriptionLength))
+	}
+	if len(p.Links) > MaxLinks {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Proposal cannot have more than %v links", MaxLinks))
+	}
+	for _, link := range p.Links {
+		if link == "" || len(link) > MaxLinkLength {
+			return tmsp.NewError(tmsp.CodeType_EncodingError,
+				Fmt("Links must be between 1 and %v bytes", MaxLinkLength))
+		}
+	}
+	if len(p.ContentHash) > MaxContentHashLength {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("ContentHash cannot be longer than %v bytes", MaxContentHashLength))
+	}
+	return tmsp.OK
+}
+
+// Members of the vote group's proposer group may propose,
+// other entities only by paying the group's proposal deposit.
+func (gov *Governmint) checkProposer(store base.KVStore, voteGroup *types.Group,
+	proposer *types.Entity, deposit base.Coins) tmsp.Result {
+	proposerGroup, ok := gov.getProposerGroup(store, voteGroup)
+	if !ok {
+		return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+			Fmt("Proposer group with id %v doesn't exist", voteGroup.Policy.ProposerGroupID))
+	}
+	if isMemberOf(proposerGroup, proposer.Addr) {
+		return tmsp.OK
+	}
+	if len(voteGroup.Policy.ProposalDeposit) == 0 {
+		return tmsp.NewError(tmsp.CodeType_Unauthorized,
+			Fmt("Proposer %X is not member of %v", proposer.Addr, proposerGroup.ID))
+	}
+	if !coinsCover(deposit, voteGroup.Policy.ProposalDeposit) {
+		return tmsp.NewError(tmsp.CodeType_InsufficientFunds,
+			Fmt("Proposal deposit must be at least %v", voteGroup.Policy.ProposalDeposit))
+	}
+	return tmsp.OK
+}
+
+// Returns the group whose members may propose to voteGroup without a deposit.
+func (gov *Governmint) getProposerGroup(store base.KVStore, voteGroup *types.Group) (*types.Group, bool) {
+	if voteGroup.Policy.ProposerGroupID == "" {
+		return voteGroup, true
+	}
+	return gov.getVotingGroup(store, voteGroup.Policy.ProposerGroupID)
+}
+
+// Returns the deposit the proposer of tx must pay,
+// nil if they may propose without one.
+// Does not write to store.
+func (gov *Governmint) ProposalDeposit(store base.KVStore, tx *types.ProposalTx) base.Coins {
+	voteGroup, ok := gov.getVotingGroup(store, tx.Proposal.VoteGroupID)
+	if !ok {
+		return nil
+	}
+	proposerGroup, ok := gov.getProposerGroup(store, voteGroup)
+	if !ok || isMemberOf(proposerGroup, tx.EntityAddr) {
+		return nil
+	}
+	return voteGroup.Policy.ProposalDeposit
+}
+
+// Returns true if coins has at least the amount of each denomination in required.
+func coinsCover(coins base.Coins, required base.Coins) bool {
+	for _, req := range required {
+		var amount int64
+		for _, coin := range coins {
+			if coin.Denom == req.Denom {
+				amount += coin.Amount
+			}
+		}
+		if amount < req.Amount {
+			return false
+		}
+	}
+	return true
+}
+
+// Validates the proposal's heights against the current height.
+// Conviction proposals have no EndHeight, so only their start is checked.
+func (gov *Governmint) validateVotingPeriod(store base.KVStore, p types.Proposal) tmsp.Result {
+	params := gov.govParams(store)
+	height := gov.GovMeta.Height
+	if p.StartHeight < height {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Proposal cannot start in the past"))
+	}
+	if p.StartHeight-height > params.MaxStartDelay {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Proposal cannot start more than %v blocks from now", params.MaxStartDelay))
+	}
+	if isConvictionProposal(&p) {
+		if p.StartTime != 0 || p.EndTime != 0 {
+			return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+				Fmt("Conviction proposals cannot have voting times"))
+		}
+		return tmsp.OK
+	}
+	if p.EndTime != 0 {
+		return gov.validateVotingTime(params, p)
+	}
+	if p.StartTime != 0 {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Proposal with a start time requires an end time"))
+	}
+	if p.EndHeight < p.StartHeight {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Proposal cannot end before it starts"))
+	}
+	minPeriod := params.MinVotingPeriod
+	if p.Emergency {
+		minPeriod = params.EmergencyMinVotingPeriod
+	}
+	period := p.EndHeight - p.StartHeight
+	if period < minPeriod || period > params.MaxVotingPeriod {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Voting period must be between %v and %v blocks", minPeriod, params.MaxVotingPeriod))
+	}
+	return tmsp.OK
+}
+
+// Validates the voting times of a proposal with an EndTime.
+func (gov *Governmint) validateVotingTime(params *types.GovParams, p types.Proposal) tmsp.Result {
+	blockTime := gov.GovMeta.BlockTime
+	if blockTime == 0 {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Voting times require a known block time"))
+	}
+	if p.EndHeight != 0 {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Proposal cannot have both an end height and an end time"))
+	}
+	if p.SecretBallot {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Secret ballots cannot have voting times"))
+	}
+	startTime := p.StartTime
+	if startTime < blockTime {
+		startTime = blockTime
+	}
+	if p.EndTime < startTime {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Proposal cannot end before it starts"))
+	}
+	duration := p.EndTime - startTime
+	if duration < params.MinVotingDuration || duration > params.MaxVotingDuration {
+		return tmsp.NewError(types.CodeType_GovInvalidVotingPeriod,
+			Fmt("Voting duration must be between %v and %v seconds",
+				params.MinVotingDuration, params.MaxVotingDuration))
+	}
+	return tmsp.OK
+}
+
+// Validates a group given at genesis.
+func (gov *Governmint) validateGroup(store base.KVStore, group *types.Group) tmsp.Result {
+	// Ensure that the group ID is not taken
+	if group.ID == "" {
+		return tmsp.NewError(tmsp.CodeType_EncodingError,
+			Fmt("Group id cannot be empty"))
+	}
+	if _, exists := gov.GetGroup(store, group.ID); exists {
+		return tmsp.NewError(tmsp.CodeType_GovDuplicateGroup,
+			Fmt("Group with id %v already exists", group.ID))
+	}
+	// Ensure that the parent group exists
+	if group.ParentID != "" {
+		if _, ok := gov.GetGroup(store, group.ParentID); !ok {
+			return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+				Fmt("Parent group with id %v doesn't exist", group.ParentID))
+		}
+	}
+	// Ensure that the policy and charter are valid
+	if res := validateGroupPolicy(group.Policy); !res.IsOK() {
+		return res
+	}
+	if res := validateGroupCharter(group.Charter); !res.IsOK() {
+		return res
+	}
+	if group.Policy.ProposerGroupID != "" {
+		if _, ok := gov.GetGroup(store, group.Policy.ProposerGroupID); !ok {
+			return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+				Fmt("Proposer group with id %v doesn't exist", group.Policy.ProposerGroupID))
+		}
+	}
+	// Ensure that the members are valid
+	return gov.validateMembers(store, group.Members, false)
+}
+
+// If allowZero, members with 0 VotingPower are allowed,
+// e.g. to remove them from a group.
+func (gov *Governmint) validateMembers(store base.KVStore, members []types.Member, allowZero bool) tmsp.Result {
+	params := gov.govParams(store)
+	// Ensure that the member entities are unique
+	if ok, dupe := validateUniqueMembers(members); !ok {
+		return tmsp.NewError(tmsp.CodeType_GovDuplicateMember,
+			Fmt("Duplicate member %X", dupe))
+	}
+	// Ensure that the member voting powers are reasonable
+	for _, member := range members {
+		if member.VotingPower == 0 && !allowZero {
+			return tmsp.NewError(tmsp.CodeType_GovInvalidVotingPower,
+				Fmt("Member cannot have 0 voting power"))
+		}
+		if member.VotingPower > params.MaxVotingPower {
+			return tmsp.NewError(tmsp.CodeType_GovInvalidVotingPower,
+				Fmt("Member voting power too large"))
+		}
+		// Ensure that the member doesn't expire right away
+		if member.ExpiresHeight != 0 && member.ExpiresHeight <= gov.GovMeta.Height {
+			return tmsp.NewError(tmsp.CodeType_GovInvalidMember,
+				Fmt("Member %X expires at past height %v", member.EntityAddr, member.ExpiresHeight))
+		}
+	}
+	// Ensure that all the entities exist
+	entityAddrs := entityAddrsFromMembers(members)
+	_, unknownEntityAddr := gov.loadEntities(store, entityAddrs)
+	if unknownEntityAddr != nil {
+		return tmsp.NewError(tmsp.CodeType_GovUnknownEntity,
+			Fmt("Member with unknown entity %X", unknownEntityAddr))
+	}
+	return tmsp.OK
+}
+
+func validateGroupPolicy(policy types.GroupPolicy) tmsp.Result {
+	if res := validateThreshold("Vote", policy.VoteThreshold); !res.IsOK() {
+		return res
+	}
+	if res := validateThreshold("Objection", policy.ObjectionThreshold); !res.IsOK() {
+		return res
+	}
+	if !isValidVotingMode(policy.VotingMode) {
+		return tmsp.NewError(types.CodeType_GovInvalidPolicy,
+			Fmt("Unknown voting mode %v", policy.VotingMode))
+	}
+	for _, coin := range policy.ProposalDeposit {
+		if coin.Denom == "" || coin.Amount <= 0 {
+			return tmsp.NewError(types.CodeType_GovInvalidPolicy,
+				Fmt("Invalid proposal deposit %v", policy.ProposalDeposit))
+		}
+	}
+	return tmsp.OK
+}
+
+// The zero Fraction is valid and means the default.
+func validateThreshold(name string, threshold types.Fraction) tmsp.Result {
+	if threshold.Denominator == 0 && threshold.Numerator != 0 {
+		return tmsp.NewError(types.CodeType_GovInvalidPolicy,
+			Fmt("%v threshold denominator cannot be 0", name))
+	}
+	if threshold.Numerator > threshold.Denominator {
+		return tmsp.NewError(types.CodeType_GovInvalidPolicy,
+			Fmt("%v threshold cannot be greater than 1", name))
+	}
+	return tmsp.OK
+}
+
+func validateGovParams(params *types.GovParams) tmsp.Result {
+	if params.MaxVotingPower == 0 || params.MaxVotingPower > MaxVotingPower {
+		return tmsp.NewError(types.CodeType_GovInvalidParams,
+			Fmt("MaxVotingPower must be between 1 and %v", MaxVotingPower))
+	}
+	if params.RevealPeriod == 0 {
+		return tmsp.NewError(types.CodeType_GovInvalidParams,
+			Fmt("RevealPeriod must be > 0"))
+	}
+	if params.ConvictionFactor == 0 {
+		return tmsp.NewError(types.CodeType_GovInvalidParams,
+			Fmt("ConvictionFactor must be > 0"))
+	}
+	if params.MinVotingPeriod > params.MaxVotingPeriod ||
+		params.EmergencyMinVotingPeriod > params.MaxVotingPeriod {
+		return tmsp.NewError(types.CodeType_GovInvalidParams,
+			Fmt("Minimum voting periods cannot exceed MaxVotingPeriod"))
+	}
+	if params.MinVotingDuration > params.MaxVotingDuration {
+		return tmsp.NewError(types.CodeType_GovInvalidParams,
+			Fmt("MinVotingDuration cannot exceed MaxVotingDuration"))
+	}
+	if params.EmergencyThreshold.Denominator == 0 {
+		return tmsp.NewError(types.CodeType_GovInvalidParams,
+			Fmt("EmergencyThreshold denominator cannot be 0"))
+	}
+	if res := validateThreshold("Emergency", params.EmergencyThreshold); !res.IsOK() {
+		return tmsp.NewError(types.CodeType_GovInvalidParams, res.Log)
+	}
+	// Ensure that emergency proposals trade a shorter voting period
+	// for a threshold at least as high as the default one
+	if lessFraction(params.EmergencyThreshold, types.DefaultVoteThreshold) {
+		return tmsp.NewError(types.CodeType_GovInvalidParams,
+			Fmt("EmergencyThreshold cannot be lower than the default vote threshold"))
+	}
+	if params.EmergencyMinVotingPeriod >= params.MinVotingPeriod {
+		return tmsp.NewError(types.CodeType_GovInvalidParams,
+			Fmt("EmergencyMinVotingPeriod must be less than MinVotingPeriod"))
+	}
+	infoTypes := map[byte]struct{}{}
+	for _, delay := range params.ExecutionDelays {
+		switch delay.InfoType {
+		case types.ProposalInfoTypeGroupCreate, types.ProposalInfoTypeGroupUpdate,
+			types.ProposalInfoTypeGroupCharter, types.ProposalInfoTypeGroupDelete,
+			types.ProposalInfoTypeText, types.ProposalInfoTypeUpgrade,
+			types.ProposalInfoTypeChoice, types.ProposalInfoTypeConviction:
+		default:
+			return tmsp.NewError(types.CodeType_GovInvalidParams,
+				Fmt("Unknown proposal info type %X", delay.InfoType))
+		}
+		if _, exists := infoTypes[delay.InfoType]; exists {
+			return tmsp.NewError(types.CodeType_GovInvalidParams,
+				Fmt("Duplicate execution delay for proposal info type %X", delay.InfoType))
+		}
+		infoTypes[delay.InfoType] = struct{}{}
+	}
+	return tmsp.OK
+}
+
+// Returns (true, "") if members are unique
+// Returns (false, <duplicateEntityAddr>) if members are not unique
+// NOTE: zero members is fine.
+func validateUniqueMembers(members []types.Member) (bool, []byte) {
+	entityAddrs := map[string]struct{}{}
+	for _, member := range members {
+		if _, exists := entityAddrs[string(member.EntityAddr)]; exists {
+			return false, member.EntityAddr
+		}
+		entityAddrs[string(member.EntityAddr)] = struct{}{}
+	}
+	return true, nil
+}
+
+func entityAddrsFromMembers(members []types.Member) [][]byte {
+	entityAddrs := make([][]byte, len(members))
+	for i, member := range members {
+		entityAddrs[i] = member.EntityAddr
+	}
+	return entityAddrs
+}
+
+// Returns (nil, <firstUnknownEntityAddr>) if any unknown
+func (gov *Governmint) loadEntities(store base.KVStore, entityAddrs [][]byte) ([]*types.Entity, []byte) {
+	entities := make([]*types.Entity, len(entityAddrs))
+	for i, entityAddr := range entityAddrs {
+		entity, ok := gov.GetEntity(store, entityAddr)
+		if !ok {
+			return nil, entityAddr
+		}
+		entities[i] = entity
+	}
+	return entities, nil
+}
+
+func isMemberOf(group *types.Group, entityAddr []byte) bool {
+	for _, member := range group.Members {
+		if bytes.Equal(member.EntityAddr, entityAddr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Members of several vote groups vote once in each.
+func hasVoted(aProposal *types.ActiveProposal, groupID string, entityAddr []byte) (bool, int) {
+	for i, sVote := range aProposal.SignedVotes {
+		if voteGroupID(aProposal, sVote.Vote) == groupID &&
+			bytes.Equal(sVote.Vote.EntityAddr, entityAddr) {
+			return true, i
+		}
+	}
+	return false, -1
+}
+
+func hasCommitted(aProposal *types.ActiveProposal, entityAddr []byte) (bool, int) {
+	for i, sCommit := range aProposal.SignedCommits {
+		if bytes.Equal(sCommit.Commit.EntityAddr, entityAddr) {
+			return true, i
+		}
+	}
+	return false, -1
+}
+
+// The group the vote was cast in.
+func voteGroupID(aProposal *types.ActiveProposal, vote types.Vote) string {
+	if vote.GroupID == "" {
+		return aProposal.VoteGroupID
+	}
+	return vote.GroupID
+}
+
+func isVoteGroupOf(p *types.Proposal, groupID string) bool {
+	if p.VoteGroupID == groupID {
+		return true
+	}
+	for _, coVoteGroup := range p.CoVoteGroups {
+		if coVoteGroup.GroupID == groupID {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(strs []string, str string) bool {
+	for _, s := range strs {
+		if s == str {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAddr(addrs [][]byte, addr []byte) bool {
+	for _, a := range addrs {
+		if bytes.Equal(a, addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Sorts by ExpiresHeight.
+type memberExpirations []types.MemberExpiration
+
+func (m memberExpirations) Len() int           { return len(m) }
+func (m memberExpirations) Less(i, j int) bool { return m[i].ExpiresHeight < m[j].ExpiresHeight }
+func (m memberExpirations) Swap(i, j int)      { m[i], m[j] = m[j], m[i] }
+
+func isConvictionProposal(p *types.Proposal) bool {
+	_, ok := p.Info.(*types.ConvictionProposalInfo)
+	return ok
+}
+
+func isValidVoteValue(aProposal *types.ActiveProposal, value string) bool {
+	if value == types.VoteValueAbstain {
+		return true
+	}
+	if pInfo, ok := aProposal.Info.(*types.ChoiceProposalInfo); ok {
+		switch pInfo.Method {
+		case types.ChoiceMethodPlurality:
+			return indexOfString(pInfo.Options, value) >= 0
+		case types.ChoiceMethodInstantRunoff:
+			// Must rank every option exactly once
+			ranking := types.ParseRanking(value)
+			if len(ranking) != len(pInfo.Options) {
+				return false
+			}
+			if ok, _ := validateUniqueStrings(ranking); !ok {
+				return false
+			}
+			for _, option := range ranking {
+				if indexOfString(pInfo.Options, option) < 0 {
+					return false
+				}
+			}
+			return true
+		default:
+			return false
+		}
+	}
+	return value == types.VoteValueYes || value == types.VoteValueNo
+}
+
+// The empty mode means the default.
+func isValidVotingMode(mode string) bool {
+	switch mode {
+	case "", types.VotingModeLinear, types.VotingModeQuadratic:
+		return true
+	default:
+		return false
+	}
+}
+
+// Returns (true, "") if strs are unique
+// Returns (false, <duplicate>) if strs are not unique
+func validateUniqueStrings(strs []string) (bool, string) {
+	seen := map[string]struct{}{}
+	for _, str := range strs {
+		if _, exists := seen[str]; exists {
+			return false, str
+		}
+		seen[str] = struct{}{}
+	}
+	return true, ""
+}
+
+func indexOfString(strs []string, str string) int {
+	for i, other := range strs {
+		if other == str {
+			return i
+		}
+	}
+	return -1
+}
+
+// Must be deferred directly.
+// Recovers a GovError into res, other panics are re-raised.
+func recoverGovError(res *tmsp.Result) {
+	if r := recover(); r != nil {
+		govErr, ok := r.(types.GovError)
+		if !ok {
+			panic(r)
+		}
+		*res = govErr.Result()
+	}
+}
+
+// Must be deferred directly.
+// Logs a GovError where there is no tmsp.Result to return,
+// other panics are re-raised.
+func logGovError(where string) {
+	if r := recover(); r != nil {
+		govErr, ok := r.(types.GovError)
+		if !ok {
+			panic(r)
+		}
+		log.Error("Error in "+where, "error", govErr.Error())
+	}
+}
+
+//----------------------------------------
+
+// Get some object, or panic with a GovError if the value is corrupt.
+// The panic is recovered into a tmsp.Result by recoverGovError.
+// objPtr: pointer to the object to populate, if value exists for key
+// Use the return value, so nil can be returned for keys with no value.
+func (gov *Governmint) getObject(store base.KVStore, key []byte, objPtr interface{}) interface{} {
+	valueBytes := store.Get(key)
+	if len(valueBytes) == 0 {
+		return nil // NOTE must use return value
+	}
+	err := wire.ReadBinaryBytes(valueBytes, objPtr)
+	if err != nil {
+		panic(types.NewGovError(types.CodeType_GovCorruptRecord,
+			Fmt("Error parsing obj at key %X: %v", key, err.Error())))
+	}
+	return objPtr
 }
 
 // Set some object, or panic
@@ -421,8 +1729,61 @@ func (gov *Governmint) GetGroup(store base.KVStore, id string) (group *types.Gro
 	}
 }
 
+// Returns the group without the members that have expired as of the current height,
+// for counting voting power. Must not be saved.
+func (gov *Governmint) getVotingGroup(store base.KVStore, id string) (group *types.Group, ok bool) {
+	group, ok = gov.GetGroup(store, id)
+	if !ok {
+		return nil, false
+	}
+	members := make([]types.Member, 0, len(group.Members))
+	for _, member := range group.Members {
+		if !member.ExpiredAt(gov.GovMeta.Height) {
+			members = append(members, member)
+		}
+	}
+	group.Members = members
+	return group, true
+}
+
+// Also keeps the group IDs up to date.
 func (gov *Governmint) SetGroup(store base.KVStore, o *types.Group) {
 	gov.setObject(store, types.GroupKey(o.ID), *o)
+	ids := gov.GetGroupIDs(store)
+	i := sort.SearchStrings(ids, o.ID)
+	if i < len(ids) && ids[i] == o.ID {
+		return
+	}
+	newIDs := make([]string, 0, len(ids)+1)
+	newIDs = append(newIDs, ids[:i]...)
+	newIDs = append(newIDs, o.ID)
+	newIDs = append(newIDs, ids[i:]...)
+	gov.SetGroupIDs(store, newIDs)
+}
+
+func (gov *Governmint) RemoveGroup(store base.KVStore, id string) {
+	store.Set(types.GroupKey(id), nil)
+	ids := gov.GetGroupIDs(store)
+	newIDs := make([]string, 0, len(ids))
+	for _, otherID := range ids {
+		if otherID != id {
+			newIDs = append(newIDs, otherID)
+		}
+	}
+	gov.SetGroupIDs(store, newIDs)
+}
+
+func (gov *Governmint) GetGroupIDs(store base.KVStore) []string {
+	obj := gov.getObject(store, types.GroupIDsKey(), &[]string{})
+	if obj == nil {
+		return nil
+	} else {
+		return *obj.(*[]string)
+	}
+}
+
+func (gov *Governmint) SetGroupIDs(store base.KVStore, ids []string) {
+	gov.setObject(store, types.GroupIDsKey(), ids)
 }
 
 func (gov *Governmint) GetActiveProposal(store base.KVStore, id string) (ap *types.ActiveProposal, ok bool) {
@@ -438,6 +1799,134 @@ func (gov *Governmint) SetActiveProposal(store base.KVStore, o *types.ActiveProp
 	gov.setObject(store, types.ActiveProposalKey(o.Proposal.ID), *o)
 }
 
+func (gov *Governmint) RemoveActiveProposal(store base.KVStore, id string) {
+	store.Set(types.ActiveProposalKey(id), nil)
+	gov.removeActiveProposalID(store, id)
+}
+
+func (gov *Governmint) GetActiveProposalIDs(store base.KVStore) []string {
+	obj := gov.getObject(store, types.ActiveProposalIDsKey(), &[]string{})
+	if obj == nil {
+		return nil
+	} else {
+		return *obj.(*[]string)
+	}
+}
+
+func (gov *Governmint) SetActiveProposalIDs(store base.KVStore, ids []string) {
+	gov.setObject(store, types.ActiveProposalIDsKey(), ids)
+}
+
+func (gov *Governmint) addActiveProposalID(store base.KVStore, id string) {
+	ids := gov.GetActiveProposalIDs(store)
+	gov.SetActiveProposalIDs(store, append(ids, id))
+}
+
+func (gov *Governmint) removeActiveProposalID(store base.KVStore, id string) {
+	ids := gov.GetActiveProposalIDs(store)
+	newIDs := make([]string, 0, len(ids))
+	for _, otherID := range ids {
+		if otherID != id {
+			newIDs = append(newIDs, otherID)
+		}
+	}
+	gov.SetActiveProposalIDs(store, newIDs)
+}
+
+func (gov *Governmint) GetClosedProposal(store base.KVStore, id string) (cp *types.ClosedProposal, ok bool) {
+	obj := gov.getObject(store, types.ClosedProposalKey(id), &types.ClosedProposal{})
+	if obj == nil {
+		return nil, false
+	} else {
+		return obj.(*types.ClosedProposal), true
+	}
+}
+
+func (gov *Governmint) SetClosedProposal(store base.KVStore, o *types.ClosedProposal) {
+	gov.setObject(store, types.ClosedProposalKey(o.Proposal.ID), *o)
+}
+
+func (gov *Governmint) GetPendingExecutions(store base.KVStore, height uint64) []string {
+	obj := gov.getObject(store, types.PendingExecutionsKey(height), &[]string{})
+	if obj == nil {
+		return nil
+	} else {
+		return *obj.(*[]string)
+	}
+}
+
+// Also keeps the pending execution heights up to date.
+func (gov *Governmint) SetPendingExecutions(store base.KVStore, height uint64, ids []string) {
+	heights := gov.GetPendingExecutionHeights(store)
+	i := 0
+	for i < len(heights) && heights[i] < height {
+		i++
+	}
+	exists := i < len(heights) && heights[i] == height
+	if len(ids) == 0 {
+		store.Set(types.PendingExecutionsKey(height), nil)
+		if exists {
+			gov.SetPendingExecutionHeights(store, append(heights[:i], heights[i+1:]...))
+		}
+		return
+	}
+	gov.setObject(store, types.PendingExecutionsKey(height), ids)
+	if !exists {
+		newHeights := make([]uint64, 0, len(heights)+1)
+		newHeights = append(newHeights, heights[:i]...)
+		newHeights = append(newHeights, height)
+		newHeights = append(newHeights, heights[i:]...)
+		gov.SetPendingExecutionHeights(store, newHeights)
+	}
+}
+
+func (gov *Governmint) addPendingExecution(store base.KVStore, height uint64, id string) {
+	ids := gov.GetPendingExecutions(store, height)
+	gov.SetPendingExecutions(store, height, append(ids, id))
+}
+
+func (gov *Governmint) GetPendingExecutionHeights(store base.KVStore) []uint64 {
+	obj := gov.getObject(store, types.PendingExecutionHeightsKey(), &[]uint64{})
+	if obj == nil {
+		return nil
+	} else {
+		return *obj.(*[]uint64)
+	}
+}
+
+func (gov *Governmint) SetPendingExecutionHeights(store base.KVStore, heights []uint64) {
+	gov.setObject(store, types.PendingExecutionHeightsKey(), heights)
+}
+
+func (gov *Governmint) removePendingExecution(store base.KVStore, height uint64, id string) {
+	ids := gov.GetPendingExecutions(store, height)
+	newIDs := make([]string, 0, len(ids))
+	for _, otherID := range ids {
+		if otherID != id {
+			newIDs = append(newIDs, otherID)
+		}
+	}
+	gov.SetPendingExecutions(store, height, newIDs)
+}
+
+func (gov *Governmint) GetProposalHashID(store base.KVStore, hash []byte) (id string, ok bool) {
+	obj := gov.getObject(store, types.ProposalHashKey(hash), new(string))
+	if obj == nil {
+		return "", false
+	} else {
+		return *obj.(*string), true
+	}
+}
+
+func (gov *Governmint) SetProposalHashID(store base.KVStore, hash []byte, id string) {
+	gov.setObject(store, types.ProposalHashKey(hash), id)
+}
+
+func proposalHash(chainID string, tx *types.ProposalTx) []byte {
+	hash := sha256.Sum256(tx.SignBytes(chainID))
+	return hash[:]
+}
+
 func (gov *Governmint) GetGovMeta(store base.KVStore) (ap *types.GovMeta, ok bool) {
 	obj := gov.getObject(store, types.GovMetaKey(), &types.GovMeta{})
 	if obj == nil {
@@ -450,3 +1939,97 @@ func (gov *Governmint) GetGovMeta(store base.KVStore) (ap *types.GovMeta, ok boo
 func (gov *Governmint) SetGovMeta(store base.KVStore, o *types.GovMeta) {
 	gov.setObject(store, types.GovMetaKey(), *o)
 }
+
+func (gov *Governmint) GetGovParams(store base.KVStore) (params *types.GovParams, ok bool) {
+	obj := gov.getObject(store, types.GovParamsKey(), &types.GovParams{})
+	if obj == nil {
+		return nil, false
+	} else {
+		return obj.(*types.GovParams), true
+	}
+}
+
+func (gov *Governmint) SetGovParams(store base.KVStore, o *types.GovParams) {
+	gov.setObject(store, types.GovParamsKey(), *o)
+}
+
+// Returns the stored GovParams, or DefaultGovParams if none were set.
+func (gov *Governmint) govParams(store base.KVStore) *types.GovParams {
+	if params, ok := gov.GetGovParams(store); ok {
+		return params
+	}
+	return DefaultGovParams()
+}
+
+func (gov *Governmint) GetRejectionHeight(store base.KVStore, entityAddr []byte) (height uint64, ok bool) {
+	obj := gov.getObject(store, types.RejectionHeightKey(entityAddr), new(uint64))
+	if obj == nil {
+		return 0, false
+	} else {
+		return *obj.(*uint64), true
+	}
+}
+
+func (gov *Governmint) SetRejectionHeight(store base.KVStore, entityAddr []byte, height uint64) {
+	gov.setObject(store, types.RejectionHeightKey(entityAddr), height)
+}
+
+func (gov *Governmint) GetDepositSettlements(store base.KVStore, height uint64) []types.DepositSettlement {
+	obj := gov.getObject(store, types.DepositSettlementsKey(height), &[]types.DepositSettlement{})
+	if obj == nil {
+		return []types.DepositSettlement{}
+	} else {
+		return *obj.(*[]types.DepositSettlement)
+	}
+}
+
+func (gov *Governmint) SetDepositSettlements(store base.KVStore, height uint64, settlements []types.DepositSettlement) {
+	gov.setObject(store, types.DepositSettlementsKey(height), settlements)
+}
+
+func (gov *Governmint) GetVoteCredits(store base.KVStore, groupID string, entityAddr []byte) (vc *types.VoteCredits, ok bool) {
+	obj := gov.getObject(store, types.VoteCreditsKey(groupID, entityAddr), &types.VoteCredits{})
+	if obj == nil {
+		return nil, false
+	} else {
+		return obj.(*types.VoteCredits), true
+	}
+}
+
+// Also keeps the group's vote credit holders up to date.
+func (gov *Governmint) SetVoteCredits(store base.KVStore, groupID string, entityAddr []byte, o *types.VoteCredits) {
+	holders := gov.GetVoteCreditHolders(store, groupID)
+	if !containsAddr(holders, entityAddr) {
+		gov.SetVoteCreditHolders(store, groupID, append(holders, entityAddr))
+	}
+	gov.setObject(store, types.VoteCreditsKey(groupID, entityAddr), *o)
+}
+
+func (gov *Governmint) GetVoteCreditHolders(store base.KVStore, groupID string) [][]byte {
+	obj := gov.getObject(store, types.VoteCreditHoldersKey(groupID), &[][]byte{})
+	if obj == nil {
+		return nil
+	} else {
+		return *obj.(*[][]byte)
+	}
+}
+
+func (gov *Governmint) SetVoteCreditHolders(store base.KVStore, groupID string, holders [][]byte) {
+	gov.setObject(store, types.VoteCreditHoldersKey(groupID), holders)
+}
+
+// Removes the vote credits of every holder in the group.
+func (gov *Governmint) removeVoteCredits(store base.KVStore, groupID string) {
+	for _, entityAddr := range gov.GetVoteCreditHolders(store, groupID) {
+		store.Set(types.VoteCreditsKey(groupID, entityAddr), nil)
+	}
+	store.Set(types.VoteCreditHoldersKey(groupID), nil)
+}
+
+// Returns zero VoteCredits if none were set.
+func (gov *Governmint) getVoteCredits(store base.KVStore, groupID string, entityAddr []byte) *types.VoteCredits {
+	if credits, ok := gov.GetVoteCredits(store, groupID, entityAddr); ok {
+		return credits
+	}
+	return &types.VoteCredits{}
+}
@@ -4,6 +4,7 @@ import (
 	base "github.com/tendermint/basecoin/types"
 	"github.com/tendermint/go-crypto"
 	"github.com/tendermint/governmint/types"
+	tmsp "github.com/tendermint/tmsp/types"
 	"testing"
 )
 
@@ -136,3 +137,218 @@ func TestUnit(t *testing.T) {
 		}
 	}
 }
+
+func TestKVCache(t *testing.T) {
+	store := base.NewMemKVStore()
+	store.Set([]byte("foo"), []byte("bar"))
+
+	cache := NewKVCache(store)
+	cache.Set([]byte("foo"), []byte("baz"))
+	cache.Set([]byte("new"), []byte("value"))
+
+	if string(cache.Get([]byte("foo"))) != "baz" {
+		t.Error("Expected cache to return buffered value")
+	}
+	if string(store.Get([]byte("foo"))) != "bar" {
+		t.Error("Expected store to be untouched before sync")
+	}
+	if len(store.Get([]byte("new"))) != 0 {
+		t.Error("Expected store to be untouched before sync")
+	}
+
+	cache.Sync()
+	if string(store.Get([]byte("foo"))) != "baz" {
+		t.Error("Expected store to be updated after sync")
+	}
+	if string(store.Get([]byte("new"))) != "value" {
+		t.Error("Expected store to be updated after sync")
+	}
+
+	cache.Set([]byte("foo"), []byte("dropped"))
+	cache.Reset()
+	cache.Sync()
+	if string(store.Get([]byte("foo"))) != "baz" {
+		t.Error("Expected reset values to be dropped")
+	}
+}
+
+type unknownTx struct{}
+
+func (tx *unknownTx) SignBytes(chainID string) []byte { return nil }
+
+func TestErrors(t *testing.T) {
+	gov := NewGovernmint()
+	store := base.NewMemKVStore()
+
+	res := gov.RunTxParsed(store, base.CallContext{}, &unknownTx{})
+	if res.Code != types.CodeType_GovUnknownTx {
+		t.Error("Expected unknown tx error, got", res.Code, res.Log)
+	}
+
+	// A corrupt record must not crash the app
+	store.Set(types.EntityKey([]byte("my_entity_id")), []byte("garbage"))
+	tx := &types.VoteTx{
+		Vote: types.Vote{
+			EntityAddr: []byte("my_entity_id"),
+			ProposalID: "my_proposal_id",
+		},
+	}
+	res = gov.RunTxParsed(store, base.CallContext{}, tx)
+	if res.Code != types.CodeType_GovCorruptRecord {
+		t.Error("Expected corrupt record error, got", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, tx)
+	if res.Code != types.CodeType_GovCorruptRecord {
+		t.Error("Expected corrupt record error, got", res.Code, res.Log)
+	}
+
+	// A corrupt record only holds up its own proposal
+	store.Set(types.GroupKey("corrupt_group_id"), []byte("garbage"))
+	gov.SetGroup(store, &types.Group{
+		ID:      "my_group_id",
+		Members: []types.Member{types.NewMember([]byte("my_entity_id"), 1)},
+	})
+	for _, aProposal := range []*types.ActiveProposal{
+		{Proposal: types.Proposal{ID: "1", VoteGroupID: "corrupt_group_id", EndHeight: 10,
+			Info: &types.TextProposalInfo{}}},
+		{Proposal: types.Proposal{ID: "2", VoteGroupID: "my_group_id", EndHeight: 1,
+			Info: &types.TextProposalInfo{}}},
+	} {
+		gov.SetActiveProposal(store, aProposal)
+		gov.addActiveProposalID(store, aProposal.ID)
+	}
+	gov.closeProposals(store, 1)
+	if _, ok := gov.GetClosedProposal(store, "2"); !ok {
+		t.Error("Expected proposal after the corrupt one to close")
+	}
+
+	// A bad validator pubkey fails genesis without writing
+	res = gov.InitValidators(store, []*tmsp.Validator{
+		&tmsp.Validator{PubKey: []byte("garbage"), Power: 1},
+	})
+	if res.Code != tmsp.CodeType_EncodingError {
+		t.Error("Expected bad validator pubkey error, got", res.Code, res.Log)
+	}
+	if _, ok := gov.GetGroup(store, types.ValidatorsGroupID); ok {
+		t.Error("Expected no validators group")
+	}
+}
+
+func TestEmergencyThreshold(t *testing.T) {
+	gov := NewGovernmint()
+	store := base.NewMemKVStore()
+	aProposal := &types.ActiveProposal{
+		Proposal: types.Proposal{
+			VoteGroupID: types.AdminGroupID,
+			Emergency:   true,
+		},
+	}
+	threshold := func(policy types.GroupPolicy) types.Fraction {
+		gov.SetGroup(store, &types.Group{ID: types.AdminGroupID, Policy: policy})
+		voteGroups, _ := gov.voteGroups(store, aProposal)
+		return voteGroups[0].Policy.Threshold()
+	}
+
+	if got := threshold(types.GroupPolicy{}); got != DefaultEmergencyThreshold {
+		t.Error("Expected the emergency threshold to replace a lower one, got", got)
+	}
+	strict := types.Fraction{Numerator: 9, Denominator: 10}
+	if got := threshold(types.GroupPolicy{VoteThreshold: strict}); got != strict {
+		t.Error("Expected a stricter group threshold to be kept, got", got)
+	}
+}
+
+func TestTallyChoices(t *testing.T) {
+	group := &types.Group{
+		ID: "my_group_id",
+		Members: []types.Member{
+			types.NewMember([]byte("entity1"), 3),
+			types.NewMember([]byte("entity2"), 2),
+			types.NewMember([]byte("entity3"), 2),
+		},
+	}
+	vote := func(entityAddr string, ranking ...string) types.SignedVote {
+		return types.SignedVote{Vote: types.Vote{
+			EntityAddr: []byte(entityAddr),
+			Value:      types.RankingValue(ranking),
+		}}
+	}
+	aProposal := &types.ActiveProposal{
+		Proposal: types.Proposal{
+			VoteGroupID: "my_group_id",
+			Info: &types.ChoiceProposalInfo{
+				Options: []string{"alice", "bob", "carol"},
+				Method:  types.ChoiceMethodInstantRunoff,
+			},
+		},
+		SignedVotes: []types.SignedVote{
+			vote("entity1", "alice", "bob", "carol"),
+			vote("entity2", "bob", "carol", "alice"),
+			vote("entity3", "carol", "bob", "alice"),
+		},
+	}
+
+	// bob and carol tie for last, carol is listed last and is eliminated
+	tally := tallyVotes(group, aProposal)
+	if tally.Winner != "bob" {
+		t.Error("Expected bob to win the instant runoff, got", tally.Winner, tally.Choices)
+	}
+	if len(tally.Choices) != 2 || tally.Choices[1].Power != 4 {
+		t.Error("Got wrong final round", tally.Choices)
+	}
+
+	// With plurality only first choices count
+	aProposal.Info.(*types.ChoiceProposalInfo).Method = types.ChoiceMethodPlurality
+	for i, sVote := range aProposal.SignedVotes {
+		aProposal.SignedVotes[i].Vote.Value = types.ParseRanking(sVote.Vote.Value)[0]
+	}
+	tally = tallyVotes(group, aProposal)
+	if tally.Winner != "alice" {
+		t.Error("Expected alice to win the plurality, got", tally.Winner, tally.Choices)
+	}
+}
+
+func TestIsqrt(t *testing.T) {
+	cases := map[uint64]uint64{0: 0, 1: 1, 2: 1, 3: 1, 4: 2, 8: 2, 9: 3, 99: 9, 100: 10,
+		1<<53 - 1: 94906265, 1<<64 - 1: 1<<32 - 1}
+	for n, root := range cases {
+		if isqrt(n) != root {
+			t.Errorf("isqrt(%v) = %v, expected %v", n, isqrt(n), root)
+		}
+	}
+}
+
+func TestLazyConsensus(t *testing.T) {
+	group := &types.Group{
+		ID: "my_group_id",
+		Members: []types.Member{
+			types.NewMember([]byte("entity1"), 1),
+			types.NewMember([]byte("entity2"), 1),
+			types.NewMember([]byte("entity3"), 1),
+		},
+	}
+	aProposal := &types.ActiveProposal{
+		Proposal: types.Proposal{
+			VoteGroupID:   "my_group_id",
+			Info:          &types.TextProposalInfo{Text: "routine change"},
+			LazyConsensus: true,
+		},
+	}
+	object := func(entityAddr string) {
+		aProposal.SignedVotes = append(aProposal.SignedVotes, types.SignedVote{
+			Vote: types.Vote{EntityAddr: []byte(entityAddr), Value: types.VoteValueNo},
+		})
+	}
+
+	if !tallyPasses(tallyVotes(group, aProposal), group, aProposal) {
+		t.Error("Expected lazy consensus to pass without votes")
+	}
+	object("entity1")
+	if !tallyPasses(tallyVotes(group, aProposal), group, aProposal) {
+		t.Error("Expected lazy consensus to pass with a third objecting")
+	}
+	object("entity2")
+	if tallyPasses(tallyVotes(group, aProposal), group, aProposal) {
+		t.Error("Expected lazy consensus to fail with two thirds objecting")
+	}
+}
@@ -0,0 +1,7 @@
+package gov
+
+import (
+	"github.com/tendermint/go-logger"
+)
+
+var log = logger.New("module", "governmint")
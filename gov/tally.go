@@ -0,0 +1,758 @@
+package gov
+
+import (
+	"bytes"
+	"math"
+	"math/big"
+	"strings"
+
+	base "github.com/tendermint/basecoin/types"
+	. "github.com/tendermint/go-common"
+	"github.com/tendermint/governmint/types"
+	tmsp "github.com/tendermint/tmsp/types"
+)
+
+// Tallies and closes every active proposal whose voting has ended,
+// or whose outcome can no longer change.
+// Each proposal is checked and closed against its own KVCache,
+// so an error in one proposal doesn't hold up the others.
+func (gov *Governmint) closeProposals(store base.KVStore, height uint64) {
+	defer logGovError("closeProposals")
+	for _, id := range gov.GetActiveProposalIDs(store) {
+		res := gov.runCached(store, func(cache base.KVStore) tmsp.Result {
+			aProposal, ok := gov.GetActiveProposal(cache, id)
+			if !ok {
+				return tmsp.OK
+			}
+			if isConvictionProposal(&aProposal.Proposal) {
+				if !gov.updateConviction(cache, aProposal, height) {
+					return tmsp.OK
+				}
+			} else if !gov.votingEnded(cache, aProposal, height) &&
+				!gov.isDecided(cache, aProposal) {
+				return tmsp.OK
+			}
+			gov.closeProposal(cache, aProposal, height)
+			return tmsp.OK
+		})
+		if !res.IsOK() {
+			log.Error("Error closing proposal", "id", id, "error", res.Log)
+		}
+	}
+}
+
+// Returns true once the proposal's voting, and reveal period, is over.
+func (gov *Governmint) votingEnded(store base.KVStore, aProposal *types.ActiveProposal, height uint64) bool {
+	if aProposal.EndTime != 0 {
+		return gov.GovMeta.BlockTime >= aProposal.EndTime
+	}
+	return height >= gov.closeHeight(store, aProposal)
+}
+
+// Returns the height at which the proposal is tallied.
+// Not used for proposals with an EndTime.
+func (gov *Governmint) closeHeight(store base.KVStore, aProposal *types.ActiveProposal) uint64 {
+	if aProposal.SecretBallot {
+		return aProposal.EndHeight + gov.govParams(store).RevealPeriod
+	}
+	return aProposal.EndHeight
+}
+
+// Recomputes and saves the conviction of a conviction proposal.
+// Returns true if the proposal has enough conviction to pass.
+func (gov *Governmint) updateConviction(store base.KVStore, aProposal *types.ActiveProposal, height uint64) bool {
+	voteGroup, ok := gov.getVotingGroup(store, aProposal.VoteGroupID)
+	if !ok {
+		return false
+	}
+	aProposal.Conviction = tallyConviction(voteGroup, aProposal, height)
+	gov.SetActiveProposal(store, aProposal)
+	pInfo := aProposal.Info.(*types.ConvictionProposalInfo)
+	return aProposal.Conviction >= gov.convictionThreshold(store, pInfo)
+}
+
+func (gov *Governmint) convictionThreshold(store base.KVStore, pInfo *types.ConvictionProposalInfo) uint64 {
+	return saturatingMul(pInfo.Amount, gov.govParams(store).ConvictionFactor)
+}
+
+// Sums the weight of each standing yes vote times the blocks it has been held.
+func tallyConviction(voteGroup *types.Group, aProposal *types.ActiveProposal, height uint64) uint64 {
+	mode := votingMode(voteGroup, aProposal)
+	conviction := new(big.Int)
+	for _, sVote := range votesInGroup(aProposal, voteGroup.ID) {
+		if sVote.Vote.Value != types.VoteValueYes || sVote.Vote.Height > height {
+			continue
+		}
+		weight := new(big.Int).SetUint64(voteWeight(voteGroup, mode, sVote.Vote))
+		blocks := new(big.Int).SetUint64(height - sVote.Vote.Height)
+		conviction.Add(conviction, weight.Mul(weight, blocks))
+	}
+	return saturatingUint64(conviction)
+}
+
+func saturatingMul(a uint64, b uint64) uint64 {
+	product := new(big.Int).Mul(new(big.Int).SetUint64(a), new(big.Int).SetUint64(b))
+	return saturatingUint64(product)
+}
+
+func saturatingUint64(n *big.Int) uint64 {
+	if n.BitLen() > 64 {
+		return math.MaxUint64
+	}
+	return n.Uint64()
+}
+
+// Returns true if the members that haven't voted yet
+// can no longer change whether the proposal passes.
+// Secret ballots and choice proposals always run their full course.
+func (gov *Governmint) isDecided(store base.KVStore, aProposal *types.ActiveProposal) bool {
+	if aProposal.SecretBallot {
+		return false
+	}
+	if _, ok := aProposal.Info.(*types.ChoiceProposalInfo); ok {
+		return false
+	}
+	voteGroups, _ := gov.voteGroups(store, aProposal)
+	if voteGroups == nil {
+		return false
+	}
+	// One group rejecting decides the proposal, otherwise all must approve
+	approved := true
+	for _, voteGroup := range voteGroups {
+		decided, passes := isDecidedInGroup(voteGroup, aProposal)
+		if decided && !passes {
+			return true
+		}
+		approved = approved && decided
+	}
+	return approved
+}
+
+// Returns whether the group's outcome is decided, and if so whether it approves.
+func isDecidedInGroup(voteGroup *types.Group, aProposal *types.ActiveProposal) (decided bool, passes bool) {
+	tally := tallyVotes(voteGroup, aProposal)
+	remaining := remainingWeight(voteGroup, aProposal)
+	if aProposal.LazyConsensus {
+		threshold := voteGroup.Policy.GetObjectionThreshold()
+		if exceedsFraction(tally.No, tally.Total, threshold) {
+			return true, false
+		}
+		return !exceedsFraction(tally.No+remaining, tally.Total, threshold), true
+	}
+	threshold := voteGroup.Policy.Threshold()
+	if exceedsFraction(tally.Yes, tally.Total, threshold) {
+		return true, true
+	}
+	return !exceedsFraction(tally.Yes+remaining, tally.Total, threshold), false
+}
+
+// Returns the groups voting on the proposal, the VoteGroupID first.
+// The thresholds of the CoVoteGroups, and the emergency threshold
+// if it is stricter than the group's own, are applied to their policies.
+// Returns (nil, <missingGroupID>) if any group doesn't exist.
+func (gov *Governmint) voteGroups(store base.KVStore, aProposal *types.ActiveProposal) ([]*types.Group, string) {
+	voteGroup, ok := gov.getVotingGroup(store, aProposal.VoteGroupID)
+	if !ok {
+		return nil, aProposal.VoteGroupID
+	}
+	if aProposal.Emergency {
+		emergencyThreshold := gov.govParams(store).EmergencyThreshold
+		if lessFraction(voteGroup.Policy.Threshold(), emergencyThreshold) {
+			voteGroup.Policy.VoteThreshold = emergencyThreshold
+		}
+	}
+	voteGroups := []*types.Group{voteGroup}
+	for _, coVoteGroup := range aProposal.CoVoteGroups {
+		group, ok := gov.getVotingGroup(store, coVoteGroup.GroupID)
+		if !ok {
+			return nil, coVoteGroup.GroupID
+		}
+		if coVoteGroup.Threshold.Denominator != 0 {
+			group.Policy.VoteThreshold = coVoteGroup.Threshold
+		}
+		voteGroups = append(voteGroups, group)
+	}
+	return voteGroups, ""
+}
+
+// The most weight that members who haven't voted yet could add.
+func remainingWeight(voteGroup *types.Group, aProposal *types.ActiveProposal) uint64 {
+	mode := votingMode(voteGroup, aProposal)
+	var remaining uint64
+	for _, member := range voteGroup.Members {
+		if voted, _ := hasVoted(aProposal, voteGroup.ID, member.EntityAddr); voted {
+			continue
+		}
+		if mode == types.VotingModeQuadratic {
+			remaining += isqrt(member.VotingPower)
+		} else {
+			remaining += member.VotingPower
+		}
+	}
+	return remaining
+}
+
+// Tallies the proposal, executes it if it passed,
+// and moves it from the active to the closed proposals.
+func (gov *Governmint) closeProposal(store base.KVStore, aProposal *types.ActiveProposal, height uint64) {
+	cProposal := &types.ClosedProposal{
+		ActiveProposal: *aProposal,
+		CloseHeight:    height,
+	}
+	voteGroups, missingID := gov.voteGroups(store, aProposal)
+	if voteGroups != nil {
+		// Every group must approve
+		cProposal.Tally = tallyVotes(voteGroups[0], aProposal)
+		cProposal.Passed = tallyPasses(cProposal.Tally, voteGroups[0], aProposal)
+		for _, coVoteGroup := range voteGroups[1:] {
+			tally := tallyVotes(coVoteGroup, aProposal)
+			passed := tallyPasses(tally, coVoteGroup, aProposal)
+			cProposal.CoTallies = append(cProposal.CoTallies, types.GroupTally{
+				GroupID: coVoteGroup.ID,
+				Tally:   tally,
+				Passed:  passed,
+			})
+			cProposal.Passed = cProposal.Passed && passed
+		}
+		if pInfo, ok := aProposal.Info.(*types.ConvictionProposalInfo); ok {
+			cProposal.Tally.Conviction = aProposal.Conviction
+			cProposal.Passed = aProposal.Conviction >= gov.convictionThreshold(store, pInfo)
+		}
+	} else {
+		cProposal.Log = Fmt("Vote group with id %v doesn't exist", missingID)
+	}
+	if cProposal.Passed {
+		params := gov.govParams(store)
+		delay := executionDelay(params, &aProposal.Proposal)
+		if parentID := voteGroups[0].ParentID; parentID != "" && params.VetoPeriod > 0 {
+			// The parent group may veto the proposal until it is executed
+			cProposal.VetoGroupID = parentID
+			if delay < params.VetoPeriod {
+				delay = params.VetoPeriod
+			}
+		}
+		if delay > 0 {
+			cProposal.ExecuteHeight = height + delay
+			cProposal.Log = Fmt("Pending execution at height %v", cProposal.ExecuteHeight)
+			gov.addPendingExecution(store, cProposal.ExecuteHeight, aProposal.ID)
+		} else {
+			gov.executeClosedProposal(store, cProposal)
+		}
+	}
+	gov.releaseVoteCredits(store, aProposal)
+	gov.settleDeposit(store, cProposal, cProposal.Passed)
+	gov.SetClosedProposal(store, cProposal)
+	gov.RemoveActiveProposal(store, aProposal.ID)
+	if !cProposal.Passed && len(aProposal.Proposer) > 0 {
+		gov.startRejectionCooldown(store, aProposal.Proposer, height)
+	}
+}
+
+// Records the rejection of one of the proposer's proposals, keeping the latest height.
+// The cooldown only applies to new proposals, see checkProposalLimits.
+func (gov *Governmint) startRejectionCooldown(store base.KVStore, proposer []byte, height uint64) {
+	if rejectionHeight, ok := gov.GetRejectionHeight(store, proposer); ok && rejectionHeight > height {
+		return
+	}
+	gov.SetRejectionHeight(store, proposer, height)
+}
+
+// Closes the proposal without a tally.
+func (gov *Governmint) cancelProposal(store base.KVStore, aProposal *types.ActiveProposal, log string) {
+	cProposal := &types.ClosedProposal{
+		ActiveProposal: *aProposal,
+		CloseHeight:    gov.GovMeta.Height,
+		Log:            log,
+		Canceled:       true,
+	}
+	gov.releaseVoteCredits(store, aProposal)
+	gov.settleDeposit(store, cProposal, true)
+	gov.SetClosedProposal(store, cProposal)
+	gov.RemoveActiveProposal(store, aProposal.ID)
+}
+
+// Records whether the closed proposal's deposit is refunded or forfeited,
+// for the host application to settle. The caller saves cProposal.
+func (gov *Governmint) settleDeposit(store base.KVStore, cProposal *types.ClosedProposal, refund bool) {
+	if len(cProposal.Deposit) == 0 {
+		return
+	}
+	cProposal.DepositRefunded = refund
+	settlements := gov.GetDepositSettlements(store, cProposal.CloseHeight)
+	gov.SetDepositSettlements(store, cProposal.CloseHeight, append(settlements, types.DepositSettlement{
+		ProposalID: cProposal.ID,
+		Proposer:   cProposal.Proposer,
+		Deposit:    cProposal.Deposit,
+		Refunded:   refund,
+	}))
+}
+
+// Keeps a passed proposal awaiting execution from being executed.
+func (gov *Governmint) cancelExecution(store base.KVStore, cProposal *types.ClosedProposal, log string) {
+	cProposal.Log = log
+	cProposal.Canceled = true
+	gov.SetClosedProposal(store, cProposal)
+	gov.removePendingExecution(store, cProposal.ExecuteHeight, cProposal.ID)
+}
+
+// Counts the voting power of the group's current members for each vote value.
+// Only votes cast in the group are counted.
+func tallyVotes(voteGroup *types.Group, aProposal *types.ActiveProposal) types.Tally {
+	tally := types.Tally{}
+	mode := votingMode(voteGroup, aProposal)
+	for _, member := range voteGroup.Members {
+		if mode == types.VotingModeQuadratic {
+			tally.Total += isqrt(member.VotingPower)
+		} else {
+			tally.Total += member.VotingPower
+		}
+	}
+	sVotes := votesInGroup(aProposal, voteGroup.ID)
+	if pInfo, ok := aProposal.Info.(*types.ChoiceProposalInfo); ok {
+		tallyChoices(&tally, pInfo, voteGroup, mode, sVotes)
+		return tally
+	}
+	for _, sVote := range sVotes {
+		power := voteWeight(voteGroup, mode, sVote.Vote)
+		switch sVote.Vote.Value {
+		case types.VoteValueYes:
+			tally.Yes += power
+		case types.VoteValueNo:
+			tally.No += power
+		case types.VoteValueAbstain:
+			tally.Abstain += power
+		}
+	}
+	return tally
+}
+
+func tallyPasses(tally types.Tally, voteGroup *types.Group, aProposal *types.ActiveProposal) bool {
+	if _, ok := aProposal.Info.(*types.ChoiceProposalInfo); ok {
+		return tally.Winner != ""
+	}
+	if aProposal.LazyConsensus {
+		return !exceedsFraction(tally.No, tally.Total, voteGroup.Policy.GetObjectionThreshold())
+	}
+	return exceedsFraction(tally.Yes, tally.Total, voteGroup.Policy.Threshold())
+}
+
+// Sets tally.Choices and tally.Winner.
+// Ties are broken in favor of the option listed first in the proposal.
+func tallyChoices(tally *types.Tally, pInfo *types.ChoiceProposalInfo,
+	voteGroup *types.Group, mode string, sVotes []types.SignedVote) {
+	// Collect the rankings with their voting power
+	rankings := make([][]string, 0, len(sVotes))
+	powers := make([]uint64, 0, len(sVotes))
+	for _, sVote := range sVotes {
+		power := voteWeight(voteGroup, mode, sVote.Vote)
+		if sVote.Vote.Value == types.VoteValueAbstain {
+			tally.Abstain += power
+			continue
+		}
+		rankings = append(rankings, types.ParseRanking(sVote.Vote.Value))
+		powers = append(powers, power)
+	}
+	// With plurality there is a single round, with instant runoff
+	// the last option is eliminated until one has a majority.
+	eliminated := make([]bool, len(pInfo.Options))
+	for {
+		counts := make([]uint64, len(pInfo.Options))
+		var total uint64
+		for i, ranking := range rankings {
+			for _, option := range ranking {
+				j := indexOfString(pInfo.Options, option)
+				if j >= 0 && !eliminated[j] {
+					counts[j] += powers[i]
+					total += powers[i]
+					break
+				}
+			}
+		}
+		tally.Choices = make([]types.ChoiceTally, 0, len(pInfo.Options))
+		best, worst, remaining := -1, -1, 0
+		for j, option := range pInfo.Options {
+			if eliminated[j] {
+				continue
+			}
+			tally.Choices = append(tally.Choices, types.ChoiceTally{Option: option, Power: counts[j]})
+			remaining++
+			if best < 0 || counts[j] > counts[best] {
+				best = j
+			}
+			if worst < 0 || counts[j] <= counts[worst] {
+				worst = j
+			}
+		}
+		if total == 0 {
+			return // Nobody voted for any option
+		}
+		if pInfo.Method == types.ChoiceMethodPlurality ||
+			remaining == 1 || exceedsFraction(counts[best], total, types.Fraction{Numerator: 1, Denominator: 2}) {
+			tally.Winner = pInfo.Options[best]
+			return
+		}
+		eliminated[worst] = true
+	}
+}
+
+func votesInGroup(aProposal *types.ActiveProposal, groupID string) []types.SignedVote {
+	sVotes := make([]types.SignedVote, 0, len(aProposal.SignedVotes))
+	for _, sVote := range aProposal.SignedVotes {
+		if voteGroupID(aProposal, sVote.Vote) == groupID {
+			sVotes = append(sVotes, sVote)
+		}
+	}
+	return sVotes
+}
+
+// The proposal's voting mode, or else the vote group's.
+func votingMode(voteGroup *types.Group, aProposal *types.ActiveProposal) string {
+	if aProposal.VotingMode != "" {
+		return aProposal.VotingMode
+	}
+	if voteGroup.Policy.VotingMode != "" {
+		return voteGroup.Policy.VotingMode
+	}
+	return types.VotingModeLinear
+}
+
+// Returns 0 if the voter is not a member of the group.
+func voteWeight(voteGroup *types.Group, mode string, vote types.Vote) uint64 {
+	power := votingPowerOf(voteGroup, vote.EntityAddr)
+	if power == 0 {
+		return 0
+	}
+	if mode == types.VotingModeQuadratic {
+		return isqrt(vote.Credits)
+	}
+	return power
+}
+
+// Integer square root, rounded down.
+func isqrt(n uint64) uint64 {
+	if n < 2 {
+		return n
+	}
+	// Newton's method, starting above the root
+	x := n/2 + 1
+	for {
+		y := (x + n/x) / 2
+		if y >= x {
+			return x
+		}
+		x = y
+	}
+}
+
+// Returns 0 if the entity is not a member of the group.
+func votingPowerOf(group *types.Group, entityAddr []byte) uint64 {
+	for _, member := range group.Members {
+		if bytes.Equal(member.EntityAddr, entityAddr) {
+			return member.VotingPower
+		}
+	}
+	return 0
+}
+
+func totalVotingPower(group *types.Group) uint64 {
+	var total uint64
+	for _, member := range group.Members {
+		total += member.VotingPower
+	}
+	return total
+}
+
+// Returns true if part/total > threshold.
+// Uses big.Int so that large voting powers don't overflow.
+func exceedsFraction(part uint64, total uint64, threshold types.Fraction) bool {
+	lhs := new(big.Int).Mul(
+		new(big.Int).SetUint64(part),
+		new(big.Int).SetUint64(threshold.Denominator))
+	rhs := new(big.Int).Mul(
+		new(big.Int).SetUint64(total),
+		new(big.Int).SetUint64(threshold.Numerator))
+	return lhs.Cmp(rhs) > 0
+}
+
+// Returns whether a is less than b.
+func lessFraction(a types.Fraction, b types.Fraction) bool {
+	lhs := new(big.Int).Mul(
+		new(big.Int).SetUint64(a.Numerator),
+		new(big.Int).SetUint64(b.Denominator))
+	rhs := new(big.Int).Mul(
+		new(big.Int).SetUint64(b.Numerator),
+		new(big.Int).SetUint64(a.Denominator))
+	return lhs.Cmp(rhs) < 0
+}
+
+//----------------------------------------
+
+// Quadratic votes must lock > 0 credits, up to the member's unlocked credits.
+// Abstentions and linear votes lock none.
+// Credits are per group, so members of several vote groups spend each separately.
+func (gov *Governmint) checkVoteCredits(store base.KVStore, aProposal *types.ActiveProposal, vote types.Vote) tmsp.Result {
+	groupID := voteGroupID(aProposal, vote)
+	voteGroup, ok := gov.getVotingGroup(store, groupID)
+	if !ok {
+		return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+			Fmt("Vote group with id %v doesn't exist", groupID))
+	}
+	if votingMode(voteGroup, aProposal) != types.VotingModeQuadratic ||
+		vote.Value == types.VoteValueAbstain {
+		if vote.Credits != 0 {
+			return tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+				Fmt("Vote cannot lock credits"))
+		}
+		return tmsp.OK
+	}
+	if vote.Credits == 0 {
+		return tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Quadratic vote must lock credits"))
+	}
+	power := votingPowerOf(voteGroup, vote.EntityAddr)
+	credits := gov.getVoteCredits(store, voteGroup.ID, vote.EntityAddr)
+	// Credits of a standing vote being replaced are available again
+	if exists, i := hasVoted(aProposal, groupID, vote.EntityAddr); exists {
+		if standing := aProposal.SignedVotes[i].Vote.Credits; credits.Locked > standing {
+			credits.Locked -= standing
+		} else {
+			credits.Locked = 0
+		}
+	}
+	if credits.Locked > power || vote.Credits > power-credits.Locked {
+		return tmsp.NewError(tmsp.CodeType_GovInvalidVote,
+			Fmt("Voter %X has insufficient credits", vote.EntityAddr))
+	}
+	return tmsp.OK
+}
+
+func (gov *Governmint) lockVoteCredits(store base.KVStore, aProposal *types.ActiveProposal, vote types.Vote) {
+	if vote.Credits == 0 {
+		return
+	}
+	groupID := voteGroupID(aProposal, vote)
+	credits := gov.getVoteCredits(store, groupID, vote.EntityAddr)
+	credits.Locked += vote.Credits
+	gov.SetVoteCredits(store, groupID, vote.EntityAddr, credits)
+}
+
+func (gov *Governmint) unlockVoteCredits(store base.KVStore, aProposal *types.ActiveProposal, vote types.Vote) {
+	if vote.Credits == 0 {
+		return
+	}
+	groupID := voteGroupID(aProposal, vote)
+	credits := gov.getVoteCredits(store, groupID, vote.EntityAddr)
+	if credits.Locked > vote.Credits {
+		credits.Locked -= vote.Credits
+	} else {
+		credits.Locked = 0
+	}
+	gov.SetVoteCredits(store, groupID, vote.EntityAddr, credits)
+}
+
+// Unlocks the credits of all votes on a closing proposal.
+func (gov *Governmint) releaseVoteCredits(store base.KVStore, aProposal *types.ActiveProposal) {
+	for _, sVote := range aProposal.SignedVotes {
+		gov.unlockVoteCredits(store, aProposal, sVote.Vote)
+	}
+}
+
+//----------------------------------------
+
+// Executes the closed proposals that are pending at or before height
+// and haven't been vetoed, in order of height.
+func (gov *Governmint) executePendingProposals(store base.KVStore, height uint64) {
+	defer logGovError("executePendingProposals")
+	for _, pendingHeight := range gov.GetPendingExecutionHeights(store) {
+		if pendingHeight > height {
+			break
+		}
+		for _, id := range gov.GetPendingExecutions(store, pendingHeight) {
+			cProposal, ok := gov.GetClosedProposal(store, id)
+			if !ok || cProposal.Vetoed || cProposal.Canceled {
+				continue
+			}
+			gov.executeClosedProposal(store, cProposal)
+			gov.SetClosedProposal(store, cProposal)
+		}
+		gov.SetPendingExecutions(store, pendingHeight, nil)
+	}
+}
+
+// Blocks between the proposal passing and its execution.
+func executionDelay(params *types.GovParams, p *types.Proposal) uint64 {
+	if p.Emergency {
+		return 0
+	}
+	infoType := types.ProposalInfoTypeOf(p.Info)
+	for _, delay := range params.ExecutionDelays {
+		if delay.InfoType == infoType {
+			return delay.Delay
+		}
+	}
+	return 0
+}
+
+// Sets cProposal.Executed and cProposal.Log, the caller saves cProposal.
+func (gov *Governmint) executeClosedProposal(store base.KVStore, cProposal *types.ClosedProposal) {
+	// Execution failures must not leave partial state behind
+	res := gov.runCached(store, func(cache base.KVStore) tmsp.Result {
+		return gov.executeProposal(cache, &cProposal.Proposal)
+	})
+	cProposal.Executed = res.IsOK()
+	cProposal.Log = res.Log
+}
+
+// Applies the effects of a passed proposal.
+func (gov *Governmint) executeProposal(store base.KVStore, p *types.Proposal) tmsp.Result {
+	switch pInfo := p.Info.(type) {
+	case *types.GroupCreateProposalInfo:
+		// Ensure that the group ID is still not taken
+		if _, exists := gov.GetGroup(store, pInfo.NewGroupID); exists {
+			return tmsp.NewError(tmsp.CodeType_GovDuplicateGroup,
+				Fmt("Group with id %v already exists", pInfo.NewGroupID))
+		}
+		// The voting group becomes the parent
+		gov.SetGroup(store, &types.Group{
+			ID:       pInfo.NewGroupID,
+			ParentID: p.VoteGroupID,
+			Version:  0,
+			Members:  pInfo.Members,
+			Charter:  pInfo.Charter,
+		})
+		return tmsp.NewResultOK(nil, "Group created")
+	case *types.GroupUpdateProposalInfo:
+		// Ensure that the update group still exists
+		updateGroup, ok := gov.GetGroup(store, pInfo.UpdateGroupID)
+		if !ok {
+			return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+				Fmt("Group with id %v doesn't exist", pInfo.UpdateGroupID))
+		}
+		// Ensure that the group hasn't changed since the proposal
+		if pInfo.NextVersion != updateGroup.Version+1 {
+			return tmsp.NewError(types.CodeType_GovInvalidGroupVersion,
+				Fmt("Group %v is at version %v, expected next version %v",
+					updateGroup.ID, updateGroup.Version, pInfo.NextVersion))
+		}
+		updateGroup.Members = applyMemberChanges(updateGroup.Members, pInfo.ChangedMembers)
+		updateGroup.Version = pInfo.NextVersion
+		gov.SetGroup(store, updateGroup)
+		return tmsp.NewResultOK(nil, "Group updated")
+	case *types.GroupCharterProposalInfo:
+		// Ensure that the update group still exists
+		updateGroup, ok := gov.GetGroup(store, pInfo.UpdateGroupID)
+		if !ok {
+			return tmsp.NewError(tmsp.CodeType_GovUnknownGroup,
+				Fmt("Group with id %v doesn't exist", pInfo.UpdateGroupID))
+		}
+		// Ensure that the group hasn't changed since the proposal
+		if pInfo.NextVersion != updateGroup.Version+1 {
+			return tmsp.NewError(types.CodeType_GovInvalidGroupVersion,
+				Fmt("Group %v is at version %v, expected next version %v",
+					updateGroup.ID, updateGroup.Version, pInfo.NextVersion))
+		}
+		updateGroup.Charter = pInfo.Charter
+		updateGroup.Version = pInfo.NextVersion
+		gov.SetGroup(store, updateGroup)
+		return tmsp.NewResultOK(nil, "Group charter updated")
+	case *types.GroupDeleteProposalInfo:
+		// Ensure that the group can still be deleted
+		deleteIDs, res := gov.checkGroupDelete(store, p.VoteGroupID, pInfo)
+		if !res.IsOK() {
+			return res
+		}
+		// Cancel the proposals voted on by the deleted groups
+		for _, id := range gov.GetActiveProposalIDs(store) {
+			aProposal, ok := gov.GetActiveProposal(store, id)
+			if !ok || id == p.ID {
+				continue
+			}
+			for _, deleteID := range deleteIDs {
+				if isVoteGroupOf(&aProposal.Proposal, deleteID) {
+					gov.cancelProposal(store, aProposal,
+						Fmt("Canceled, vote group %v was deleted", deleteID))
+					break
+				}
+			}
+		}
+		// Cancel the passed proposals of the deleted groups that are awaiting execution.
+		// Those of the surviving children may be vetoed by their new parent instead.
+		for _, height := range gov.GetPendingExecutionHeights(store) {
+			for _, id := range gov.GetPendingExecutions(store, height) {
+				cProposal, ok := gov.GetClosedProposal(store, id)
+				if !ok {
+					continue
+				}
+				if containsString(deleteIDs, cProposal.VoteGroupID) {
+					gov.cancelExecution(store, cProposal,
+						Fmt("Canceled, vote group %v was deleted", cProposal.VoteGroupID))
+				} else if containsString(deleteIDs, cProposal.VetoGroupID) {
+					cProposal.VetoGroupID = p.VoteGroupID
+					gov.SetClosedProposal(store, cProposal)
+				}
+			}
+		}
+		// The group's children are given its parent
+		if !pInfo.Cascade {
+			for _, id := range gov.GetGroupIDs(store) {
+				if group, ok := gov.GetGroup(store, id); ok && group.ParentID == pInfo.DeleteGroupID {
+					group.ParentID = p.VoteGroupID
+					group.Version++
+					gov.SetGroup(store, group)
+				}
+			}
+		}
+		for _, deleteID := range deleteIDs {
+			gov.removeVoteCredits(store, deleteID)
+			gov.RemoveGroup(store, deleteID)
+		}
+		return tmsp.NewResultOK(nil, Fmt("Deleted groups %v", strings.Join(deleteIDs, ", ")))
+	case *types.TextProposalInfo:
+		return tmsp.NewResultOK(nil, "")
+	case *types.UpgradeProposalInfo:
+		// The upgrade itself is left to the host application
+		return tmsp.NewResultOK(nil, "")
+	case *types.ChoiceProposalInfo:
+		// The outcome is recorded in the tally
+		return tmsp.NewResultOK(nil, "")
+	case *types.ConvictionProposalInfo:
+		// Paying out the funds is left to the host application
+		return tmsp.NewResultOK(nil, Fmt("Funding of %v approved for %X",
+			pInfo.Amount, pInfo.Recipient))
+	default:
+		return tmsp.NewError(types.CodeType_GovUnknownProposalInfo,
+			"Unknown proposal info type")
+	}
+}
+
+// Members with 0 VotingPower are removed, others are updated or added.
+func applyMemberChanges(members []types.Member, changes []types.Member) []types.Member {
+	newMembers := make([]types.Member, 0, len(members)+len(changes))
+	for _, member := range members {
+		if i := indexOfMember(changes, member.EntityAddr); i >= 0 {
+			if changes[i].VotingPower == 0 {
+				continue
+			}
+			member = changes[i]
+		}
+		newMembers = append(newMembers, member)
+	}
+	for _, change := range changes {
+		if change.VotingPower == 0 {
+			continue
+		}
+		if indexOfMember(members, change.EntityAddr) < 0 {
+			newMembers = append(newMembers, change)
+		}
+	}
+	return newMembers
+}
+
+func indexOfMember(members []types.Member, entityAddr []byte) int {
+	for i, member := range members {
+		if bytes.Equal(member.EntityAddr, entityAddr) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,202 @@
+package tests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	base "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
+	"github.com/tendermint/governmint/app"
+	govutil "github.com/tendermint/governmint/testutil"
+	"github.com/tendermint/governmint/types"
+	tmspcli "github.com/tendermint/tmsp/client"
+	"github.com/tendermint/tmsp/server"
+	tmsputil "github.com/tendermint/tmsp/testutil"
+	tmsp "github.com/tendermint/tmsp/types"
+	"testing"
+)
+
+func TestApp(t *testing.T) {
+
+	// A unix socket in a temp dir, so that parallel runs don't share an address
+	dir, err := ioutil.TempDir("", "governmint")
+	if err != nil {
+		t.Fatal("Error creating temp dir", err)
+	}
+	defer os.RemoveAll(dir)
+	addr := "unix://" + filepath.Join(dir, "governmint.sock")
+	srv, err := server.NewServer(addr, "socket", app.NewGovernmintApp())
+	if err != nil {
+		t.Fatal("Error starting server", err)
+	}
+	defer srv.Stop()
+
+	client, err := tmspcli.NewClient(addr, "socket", true)
+	if err != nil {
+		t.Fatal("Error starting client", err)
+	}
+	defer client.Stop()
+
+	if log, err := client.SetOptionSync("chain_id", chainID); err != nil || log != "Success" {
+		t.Fatal("Error setting chain id", log, err)
+	}
+	if err := client.InitChainSync([]*tmsp.Validator{
+		tmsputil.Validator("validator1", 1),
+		tmsputil.Validator("validator2", 1),
+	}); err != nil {
+		t.Fatal("Error on InitChain", err)
+	}
+
+	// Propose something to the validators group
+	privKey := crypto.GenPrivKeyEd25519FromSecret([]byte("validator1"))
+	proposal := types.Proposal{
+		Title:       "my_proposal",
+		VoteGroupID: types.ValidatorsGroupID,
+		StartHeight: 1,
+		EndHeight:   10,
+		Info:        &types.TextProposalInfo{Text: "hello"},
+	}
+	txBytes := wire.BinaryBytes(struct{ types.Tx }{&types.ProposalTx{
+		EntityAddr: privKey.PubKey().Address(),
+		Proposal:   proposal,
+		Signature:  privKey.Sign(proposal.SignBytes(chainID)),
+	}})
+
+	if err := client.BeginBlockSync(1); err != nil {
+		t.Fatal("Error on BeginBlock", err)
+	}
+	if res := client.CheckTxSync(txBytes); !res.IsOK() {
+		t.Fatal("Expected CheckTx to pass", res.Log)
+	}
+	if res := client.AppendTxSync(txBytes); !res.IsOK() || string(res.Data) != "1" {
+		t.Fatal("Expected AppendTx to pass with the proposal id", res.Log)
+	}
+	if res := client.CheckTxSync(txBytes); res.Code != tmsp.CodeType_GovDuplicateProposal {
+		t.Error("Expected duplicate proposal, got", res.Code, res.Log)
+	}
+
+	// The app has no block time, so proposals with voting times are rejected
+	timedProposal := proposal
+	timedProposal.EndHeight = 0
+	timedProposal.EndTime = 1000
+	timedTxBytes := wire.BinaryBytes(struct{ types.Tx }{&types.ProposalTx{
+		EntityAddr: privKey.PubKey().Address(),
+		Proposal:   timedProposal,
+		Signature:  privKey.Sign(timedProposal.SignBytes(chainID)),
+	}})
+	if res := client.CheckTxSync(timedTxBytes); res.Code != types.CodeType_GovInvalidVotingPeriod {
+		t.Error("Expected timed proposal to be rejected, got", res.Code, res.Log)
+	}
+	if res := client.AppendTxSync(timedTxBytes); res.Code != types.CodeType_GovInvalidVotingPeriod {
+		t.Error("Expected timed proposal to be rejected, got", res.Code, res.Log)
+	}
+
+	if _, err := client.EndBlockSync(1); err != nil {
+		t.Fatal("Error on EndBlock", err)
+	}
+	res := client.CommitSync()
+	if !res.IsOK() || len(res.Data) == 0 {
+		t.Fatal("Expected app hash on Commit", res.Log)
+	}
+
+	// Query the proposal back out
+	res = client.QuerySync(wire.BinaryBytes(struct{ types.Query }{
+		&types.ActiveProposalQuery{ProposalID: "1"},
+	}))
+	if !res.IsOK() {
+		t.Fatal("Expected query to pass", res.Log)
+	}
+	var aProposal types.ActiveProposal
+	if err := wire.ReadBinaryBytes(res.Data, &aProposal); err != nil {
+		t.Fatal("Error decoding query result", err)
+	}
+	if aProposal.VoteGroupID != types.ValidatorsGroupID {
+		t.Error("Got wrong proposal vote group id")
+	}
+}
+
+func TestAppDeposits(t *testing.T) {
+
+	gmApp := app.NewGovernmintApp()
+	gmApp.SetOption("chain_id", chainID)
+	gmApp.InitChain([]*tmsp.Validator{
+		tmsputil.Validator("validator1", 1),
+		tmsputil.Validator("validator2", 1),
+	})
+	// The validators' entities are addressed by their pubkeys
+	validatorAddr := func(secret string) []byte {
+		return crypto.GenPrivKeyEd25519FromSecret([]byte(secret)).PubKey().Address()
+	}
+
+	// Entities outside the council pay a deposit to propose to it
+	council := types.Group{
+		ID: "council_id",
+		Members: []types.Member{
+			{EntityAddr: validatorAddr("validator1"), VotingPower: 1},
+		},
+		Policy: types.GroupPolicy{
+			ProposalDeposit: base.Coins{{Denom: "mycoin", Amount: 10}},
+		},
+	}
+	if log := gmApp.SetOption("group", string(wire.JSONBytes(council))); log != "Success" {
+		t.Fatal("Error setting council group", log)
+	}
+	proposer := validatorAddr("validator2")
+	account := app.Account{
+		Addr:  proposer,
+		Coins: base.Coins{{Denom: "mycoin", Amount: 15}},
+	}
+	if log := gmApp.SetOption("account", string(wire.JSONBytes(account))); log != "Success" {
+		t.Fatal("Error setting account", log)
+	}
+
+	txBytes := func(tx types.Tx) []byte {
+		return wire.BinaryBytes(struct{ types.Tx }{tx})
+	}
+	proposalTxBytes := func(text string, height uint64) []byte {
+		tx := govutil.ProposalTx(chainID, "validator2", "council_id", height, height+10,
+			&types.TextProposalInfo{Text: text})
+		tx.EntityAddr = proposer
+		return txBytes(tx)
+	}
+	voteTxBytes := func(height uint64, proposalID string, value string) []byte {
+		tx := govutil.VoteTx(chainID, "validator1", height, proposalID, value)
+		tx.Vote.EntityAddr = validatorAddr("validator1")
+		tx.Signature = govutil.SignVote(chainID, "validator1", tx.Vote)
+		return txBytes(tx)
+	}
+	checkBalance := func(amount int64) {
+		balance := gmApp.Balance(proposer)
+		if len(balance) != 1 || balance[0].Amount != amount {
+			t.Error("Expected balance of", amount, "got", balance)
+		}
+	}
+
+	// The deposit of a passed proposal is refunded
+	gmApp.BeginBlock(1)
+	if res := gmApp.AppendTx(proposalTxBytes("refunded", 1)); !res.IsOK() {
+		t.Fatal("Expected proposal with a deposit to pass", res.Log)
+	}
+	checkBalance(5)
+	if res := gmApp.CheckTx(proposalTxBytes("unpaid", 1)); res.Code != tmsp.CodeType_InsufficientFunds {
+		t.Error("Expected proposal without enough balance to fail", res.Code, res.Log)
+	}
+	if res := gmApp.AppendTx(voteTxBytes(1, "1", types.VoteValueYes)); !res.IsOK() {
+		t.Fatal("Expected vote to pass", res.Log)
+	}
+	gmApp.EndBlock(1)
+	checkBalance(15)
+
+	// The deposit of a rejected proposal is forfeited
+	gmApp.BeginBlock(2)
+	if res := gmApp.AppendTx(proposalTxBytes("forfeited", 2)); !res.IsOK() {
+		t.Fatal("Expected proposal with a deposit to pass", res.Log)
+	}
+	if res := gmApp.AppendTx(voteTxBytes(2, "2", types.VoteValueNo)); !res.IsOK() {
+		t.Fatal("Expected vote to pass", res.Log)
+	}
+	gmApp.EndBlock(2)
+	checkBalance(5)
+}
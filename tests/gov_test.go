@@ -2,14 +2,19 @@ package tests
 
 import (
 	base "github.com/tendermint/basecoin/types"
+	"github.com/tendermint/go-crypto"
+	"github.com/tendermint/go-wire"
 	gm "github.com/tendermint/governmint/gov"
 	govutil "github.com/tendermint/governmint/testutil"
 	"github.com/tendermint/governmint/types"
 	tmsputil "github.com/tendermint/tmsp/testutil"
 	tmsp "github.com/tendermint/tmsp/types"
+	"strings"
 	"testing"
 )
 
+const chainID = "test_chain_id"
+
 func TestIntegration(t *testing.T) {
 
 	gov := gm.NewGovernmint()
@@ -22,8 +27,8 @@ func TestIntegration(t *testing.T) {
 		tmsputil.Validator("entity3", 1),
 	})
 
-	res := gov.RunTxParsed(store, govutil.ProposalTx("secret1",
-		"my_proposal_id", "my_vote_group_id", 0, 1,
+	res := gov.RunTxParsed(store, base.CallContext{}, govutil.ProposalTx(chainID, "secret1",
+		"my_vote_group_id", 0, 1,
 		&types.GroupCreateProposalInfo{
 			NewGroupID: "new_group_id",
 			Members:    govutil.Members([]string{"entity1"}, 1),
@@ -32,3 +37,1331 @@ func TestIntegration(t *testing.T) {
 
 	t.Log(res.Code, res.Data, res.Log)
 }
+
+// Creates entities for secrets and a group of them with voting power 1.
+func setupGroup(gov *gm.Governmint, store base.KVStore, groupID string, secrets []string) {
+	for _, privEntity := range govutil.Entities(secrets) {
+		entity := privEntity.Entity
+		gov.SetEntity(store, &entity)
+	}
+	gov.SetGroup(store, &types.Group{
+		ID:      groupID,
+		Version: 0,
+		Members: govutil.Members(secrets, 1),
+	})
+}
+
+func TestCheckTx(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2"})
+
+	proposalTx := govutil.ProposalTx(chainID, "secret1",
+		"my_group_id", 0, 10,
+		&types.TextProposalInfo{Text: "hello"},
+	)
+	res := gov.CheckTxParsed(store, base.CallContext{}, proposalTx)
+	if !res.IsOK() {
+		t.Fatal("Expected valid proposal tx", res.Log)
+	}
+	if _, ok := gov.GetActiveProposal(store, "1"); ok {
+		t.Error("CheckTx should not create the proposal")
+	}
+
+	// Signed by the wrong entity
+	badTx := govutil.ProposalTx(chainID, "secret2",
+		"my_group_id", 0, 10,
+		&types.TextProposalInfo{Text: "hello"},
+	)
+	badTx.EntityAddr = govutil.EntityAddr("secret1")
+	res = gov.CheckTxParsed(store, base.CallContext{}, badTx)
+	if res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected unauthorized, got", res.Code, res.Log)
+	}
+
+	// Vote for an unknown proposal
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.VoteTx(chainID, "secret2", 0, "1", "yes"))
+	if res.Code != tmsp.CodeType_GovUnknownProposal {
+		t.Error("Expected unknown proposal, got", res.Code, res.Log)
+	}
+
+	// Vote for a known proposal
+	res = gov.RunTxParsed(store, base.CallContext{}, proposalTx)
+	if !res.IsOK() || string(res.Data) != "1" {
+		t.Fatal("Expected proposal to be created with id 1", res.Log)
+	}
+	// The same proposal can't be replayed
+	res = gov.CheckTxParsed(store, base.CallContext{}, proposalTx)
+	if res.Code != tmsp.CodeType_GovDuplicateProposal {
+		t.Error("Expected duplicate proposal, got", res.Code, res.Log)
+	}
+	// IDs are assigned by governmint
+	idTx := govutil.ProposalTx(chainID, "secret1", "my_group_id", 0, 10,
+		&types.TextProposalInfo{Text: "hello"})
+	idTx.Proposal.ID = "my_proposal_id"
+	idTx.Signature = govutil.SignProposal(chainID, "secret1", idTx.Proposal)
+	res = gov.CheckTxParsed(store, base.CallContext{}, idTx)
+	if res.Code != tmsp.CodeType_EncodingError {
+		t.Error("Expected proposal with an id to fail, got", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.VoteTx(chainID, "secret2", 0, "1", "yes"))
+	if !res.IsOK() {
+		t.Error("Expected valid vote tx", res.Log)
+	}
+	aProposal, _ := gov.GetActiveProposal(store, "1")
+	if len(aProposal.SignedVotes) != 0 {
+		t.Error("CheckTx should not record votes")
+	}
+}
+
+func TestSetOption(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2"})
+
+	if log := gov.SetOption(store, "chain_id", chainID); log != "Success" {
+		t.Error("Expected chain_id to be set", log)
+	}
+	if gov.GovMeta.ChainID != chainID {
+		t.Error("Got wrong chain id")
+	}
+
+	childGroup := types.Group{
+		ID:       "child_group_id",
+		ParentID: "my_group_id",
+		Members:  govutil.Members([]string{"secret1"}, 1),
+		Policy: types.GroupPolicy{
+			VoteThreshold: types.Fraction{Numerator: 2, Denominator: 3},
+		},
+	}
+	childJSON := string(wire.JSONBytes(childGroup))
+	if log := gov.SetOption(store, "group", childJSON); log != "Success" {
+		t.Fatal("Expected group to be created", log)
+	}
+	groupCopy, ok := gov.GetGroup(store, "child_group_id")
+	if !ok {
+		t.Fatal("Expected group to exist")
+	}
+	if groupCopy.ParentID != "my_group_id" || groupCopy.Policy.Threshold().Numerator != 2 {
+		t.Error("Got wrong group parent or policy")
+	}
+	if log := gov.SetOption(store, "group", childJSON); log == "Success" {
+		t.Error("Expected duplicate group to fail")
+	}
+
+	childGroup.ID = "orphan_group_id"
+	childGroup.ParentID = "unknown_group_id"
+	if log := gov.SetOption(store, "group", string(wire.JSONBytes(childGroup))); log == "Success" {
+		t.Error("Expected group with unknown parent to fail")
+	}
+	if _, ok := gov.GetGroup(store, "orphan_group_id"); ok {
+		t.Error("Failed option should not write")
+	}
+
+	params := *gm.DefaultGovParams()
+	params.MaxVotingPower = 10
+	if log := gov.SetOption(store, "params", string(wire.JSONBytes(params))); log != "Success" {
+		t.Error("Expected params to be set", log)
+	}
+	params.MaxVotingPower = 0
+	if log := gov.SetOption(store, "params", string(wire.JSONBytes(params))); log == "Success" {
+		t.Error("Expected invalid params to fail")
+	}
+	params = *gm.DefaultGovParams()
+	params.EmergencyThreshold = types.Fraction{Numerator: 1, Denominator: 3}
+	if log := gov.SetOption(store, "params", string(wire.JSONBytes(params))); log == "Success" {
+		t.Error("Expected emergency threshold below the default to fail")
+	}
+	params = *gm.DefaultGovParams()
+	params.EmergencyMinVotingPeriod = params.MinVotingPeriod
+	if log := gov.SetOption(store, "params", string(wire.JSONBytes(params))); log == "Success" {
+		t.Error("Expected emergency voting period that isn't shorter to fail")
+	}
+
+	if log := gov.SetOption(store, "unknown", ""); log == "Success" {
+		t.Error("Expected unknown option key to fail")
+	}
+}
+
+// Runs BeginBlock and EndBlock for each height, running txs[height] in between.
+func runBlocks(t *testing.T, gov *gm.Governmint, store base.KVStore,
+	from uint64, to uint64, txs map[uint64][]types.Tx) {
+	for height := from; height <= to; height++ {
+		gov.BeginBlock(store, height)
+		for _, tx := range txs[height] {
+			if res := gov.RunTxParsed(store, base.CallContext{}, tx); !res.IsOK() {
+				t.Fatal("Expected tx to pass at height", height, res.Log)
+			}
+		}
+		gov.EndBlock(store, height)
+	}
+}
+
+func TestProposalLifecycle(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2", "secret3"})
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 2,
+				&types.GroupCreateProposalInfo{
+					NewGroupID: "new_group_id",
+					Members:    govutil.Members([]string{"secret1"}, 1),
+				},
+			),
+			govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes),
+		},
+	})
+
+	// Invalid vote value
+	res := gov.CheckTxParsed(store, base.CallContext{}, govutil.VoteTx(chainID, "secret3", 1, "1", "maybe"))
+	if res.Code != tmsp.CodeType_GovInvalidVote {
+		t.Error("Expected invalid vote value to fail", res.Code, res.Log)
+	}
+
+	runBlocks(t, gov, store, 2, 3, map[uint64][]types.Tx{
+		2: []types.Tx{
+			govutil.VoteTx(chainID, "secret2", 2, "1", types.VoteValueYes),
+		},
+	})
+
+	if _, ok := gov.GetActiveProposal(store, "1"); ok {
+		t.Error("Expected proposal to be closed")
+	}
+	cProposal, ok := gov.GetClosedProposal(store, "1")
+	if !ok {
+		t.Fatal("Expected closed proposal")
+	}
+	if !cProposal.Passed || !cProposal.Executed {
+		t.Error("Expected proposal to pass and execute", cProposal.Log)
+	}
+	if cProposal.Tally.Yes != 2 || cProposal.Tally.Total != 3 || cProposal.CloseHeight != 2 {
+		t.Error("Got wrong tally", cProposal.Tally, cProposal.CloseHeight)
+	}
+	newGroup, ok := gov.GetGroup(store, "new_group_id")
+	if !ok || newGroup.ParentID != "my_group_id" {
+		t.Error("Expected new group with my_group_id as parent")
+	}
+}
+
+func TestSecretBallot(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	gov.SetOption(store, "params", `{"reveal_period":2}`)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2", "secret3", "secret4"})
+
+	proposalTx := govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 2,
+		&types.TextProposalInfo{Text: "hello"})
+	proposalTx.Proposal.SecretBallot = true
+	proposalTx.Signature = govutil.SignProposal(chainID, "secret1", proposalTx.Proposal)
+
+	// secret4 copies secret1's commit
+	commitTx1 := govutil.VoteCommitTx(chainID, "secret1", 1, "1", "my_group_id", types.VoteValueYes, "salt1")
+	copiedTx := govutil.VoteCommitTx(chainID, "secret4", 1, "1", "my_group_id", types.VoteValueYes, "salt4")
+	copiedTx.Commit.Hash = commitTx1.Commit.Hash
+	copiedTx.Signature = govutil.SignVoteCommit(chainID, "secret4", copiedTx.Commit)
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			proposalTx,
+			commitTx1,
+			govutil.VoteCommitTx(chainID, "secret2", 1, "1", "my_group_id", types.VoteValueYes, "salt2"),
+			govutil.VoteCommitTx(chainID, "secret3", 1, "1", "my_group_id", types.VoteValueNo, "salt3"),
+			copiedTx,
+		},
+	})
+
+	// Plain votes are not allowed
+	res := gov.CheckTxParsed(store, base.CallContext{}, govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes))
+	if res.IsOK() {
+		t.Error("Expected plain vote on secret ballot to fail")
+	}
+	// Commits are secret until EndHeight
+	aProposal, _ := gov.GetActiveProposal(store, "1")
+	if len(aProposal.SignedVotes) != 0 || len(aProposal.SignedCommits) != 4 {
+		t.Error("Expected only commits")
+	}
+
+	runBlocks(t, gov, store, 2, 3, map[uint64][]types.Tx{
+		3: []types.Tx{
+			govutil.VoteRevealTx(chainID, "secret1", 3, "1", types.VoteValueYes, "salt1"),
+			govutil.VoteRevealTx(chainID, "secret3", 3, "1", types.VoteValueNo, "salt3"),
+		},
+	})
+
+	// Reveal that doesn't match the commit
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.VoteRevealTx(chainID, "secret2", 3, "1", types.VoteValueNo, "salt2"))
+	if res.IsOK() {
+		t.Error("Expected mismatched reveal to fail")
+	}
+	// A copied commit can't be revealed with the revealed salt
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.VoteRevealTx(chainID, "secret4", 3, "1", types.VoteValueYes, "salt1"))
+	if res.Code != tmsp.CodeType_GovInvalidVote {
+		t.Error("Expected reveal of a copied commit to fail", res.Code, res.Log)
+	}
+
+	runBlocks(t, gov, store, 4, 4, nil)
+
+	cProposal, ok := gov.GetClosedProposal(store, "1")
+	if !ok {
+		t.Fatal("Expected closed proposal")
+	}
+	if cProposal.Passed || cProposal.Tally.Yes != 1 || cProposal.Tally.No != 1 {
+		t.Error("Expected only revealed votes to count", cProposal.Tally)
+	}
+}
+
+func TestQuadraticVoting(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2", "secret3"})
+	group, _ := gov.GetGroup(store, "my_group_id")
+	group.Members = govutil.Members([]string{"secret1", "secret2", "secret3"}, 9)
+	group.Policy.VotingMode = types.VotingModeQuadratic
+	gov.SetGroup(store, group)
+
+	creditVoteTx := func(secret string, proposalID string, credits uint64) *types.VoteTx {
+		tx := govutil.VoteTx(chainID, secret, 1, proposalID, types.VoteValueYes)
+		tx.Vote.Credits = credits
+		tx.Signature = govutil.SignVote(chainID, secret, tx.Vote)
+		return tx
+	}
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 2,
+				&types.TextProposalInfo{Text: "one"}),
+			govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 5,
+				&types.TextProposalInfo{Text: "two"}),
+			creditVoteTx("secret1", "1", 9),
+			creditVoteTx("secret2", "2", 5),
+		},
+	})
+
+	// secret1 has locked all of its credits
+	res := gov.CheckTxParsed(store, base.CallContext{}, creditVoteTx("secret1", "2", 1))
+	if res.Code != tmsp.CodeType_GovInvalidVote {
+		t.Error("Expected insufficient credits, got", res.Code, res.Log)
+	}
+	// Votes must lock credits
+	res = gov.CheckTxParsed(store, base.CallContext{}, creditVoteTx("secret3", "2", 0))
+	if res.Code != tmsp.CodeType_GovInvalidVote {
+		t.Error("Expected vote without credits to fail, got", res.Code, res.Log)
+	}
+
+	// secret2 votes last, as its vote decides proposal1 and closes it early
+	runBlocks(t, gov, store, 2, 2, map[uint64][]types.Tx{
+		2: []types.Tx{creditVoteTx("secret2", "1", 4)},
+	})
+
+	// sqrt(9) + sqrt(4) = 5 out of 3 * sqrt(9) = 9
+	cProposal, _ := gov.GetClosedProposal(store, "1")
+	if cProposal.Tally.Yes != 5 || cProposal.Tally.Total != 9 || !cProposal.Passed {
+		t.Error("Got wrong quadratic tally", cProposal.Tally)
+	}
+	// Credits are released when the proposal closes
+	credits, _ := gov.GetVoteCredits(store, "my_group_id", govutil.EntityAddr("secret1"))
+	if credits.Locked != 0 {
+		t.Error("Expected credits to be released, got", credits.Locked)
+	}
+	credits, _ = gov.GetVoteCredits(store, "my_group_id", govutil.EntityAddr("secret2"))
+	if credits.Locked != 5 {
+		t.Error("Expected credits to stay locked on proposal2, got", credits.Locked)
+	}
+}
+
+func TestQuadraticSecretBallot(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	gov.SetOption(store, "params", `{"reveal_period":2}`)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2"})
+	group, _ := gov.GetGroup(store, "my_group_id")
+	group.Members = govutil.Members([]string{"secret1", "secret2"}, 9)
+	group.Policy.VotingMode = types.VotingModeQuadratic
+	gov.SetGroup(store, group)
+
+	proposalTx := govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 2,
+		&types.TextProposalInfo{Text: "hello"})
+	proposalTx.Proposal.SecretBallot = true
+	proposalTx.Signature = govutil.SignProposal(chainID, "secret1", proposalTx.Proposal)
+
+	// The credits are committed with the vote
+	commitTx := govutil.VoteCommitTx(chainID, "secret1", 1, "1", "my_group_id", types.VoteValueYes, "salt1")
+	commitTx.Commit.Hash = types.VoteCommitHash(chainID, "1", "my_group_id",
+		govutil.EntityAddr("secret1"), types.VoteValueYes, 4, "salt1")
+	commitTx.Signature = govutil.SignVoteCommit(chainID, "secret1", commitTx.Commit)
+
+	runBlocks(t, gov, store, 1, 3, map[uint64][]types.Tx{
+		1: []types.Tx{proposalTx, commitTx},
+	})
+
+	revealTx := func(credits uint64) *types.VoteRevealTx {
+		tx := govutil.VoteRevealTx(chainID, "secret1", 3, "1", types.VoteValueYes, "salt1")
+		tx.Vote.Credits = credits
+		tx.Signature = govutil.SignVote(chainID, "secret1", tx.Vote)
+		return tx
+	}
+	res := gov.CheckTxParsed(store, base.CallContext{}, revealTx(9))
+	if res.Code != tmsp.CodeType_GovInvalidVote {
+		t.Error("Expected reveal with other credits to fail", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, revealTx(4))
+	if !res.IsOK() {
+		t.Error("Expected reveal with the committed credits to pass", res.Log)
+	}
+}
+
+func TestConvictionVoting(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	gov.SetOption(store, "params", `{"conviction_factor":1}`)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2", "secret3"})
+
+	runBlocks(t, gov, store, 1, 2, map[uint64][]types.Tx{
+		1: []types.Tx{
+			govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 0,
+				&types.ConvictionProposalInfo{
+					Recipient: govutil.EntityAddr("secret3"),
+					Amount:    4,
+				}),
+			govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes),
+			govutil.VoteTx(chainID, "secret2", 1, "1", types.VoteValueYes),
+		},
+		// secret2 withdraws its support
+		2: []types.Tx{
+			govutil.VoteTx(chainID, "secret2", 2, "1", types.VoteValueNo),
+		},
+	})
+
+	aProposal, ok := gov.GetActiveProposal(store, "1")
+	if !ok || aProposal.Conviction != 1 || len(aProposal.SignedVotes) != 2 {
+		t.Fatal("Expected conviction of 1 from secret1 only")
+	}
+
+	runBlocks(t, gov, store, 3, 4, nil)
+	if _, ok := gov.GetActiveProposal(store, "1"); !ok {
+		t.Fatal("Expected proposal to still be active")
+	}
+
+	runBlocks(t, gov, store, 5, 5, nil)
+	cProposal, ok := gov.GetClosedProposal(store, "1")
+	if !ok {
+		t.Fatal("Expected proposal to close with enough conviction")
+	}
+	if !cProposal.Passed || cProposal.Tally.Conviction != 4 || cProposal.CloseHeight != 5 {
+		t.Error("Got wrong conviction result", cProposal.Tally, cProposal.CloseHeight)
+	}
+}
+
+func TestLazyConsensusOptIn(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2", "secret3"})
+	setupGroup(gov, store, "lazy_group_id", []string{"secret1", "secret2", "secret3"})
+	lazyGroup, _ := gov.GetGroup(store, "lazy_group_id")
+	lazyGroup.Policy.AllowLazyConsensus = true
+	gov.SetGroup(store, lazyGroup)
+
+	propose := func(text string, voteGroupID string, coVoteGroupID string) tmsp.Result {
+		proposalTx := govutil.ProposalTx(chainID, "secret1", voteGroupID, 0, 10,
+			&types.TextProposalInfo{Text: text})
+		proposalTx.Proposal.LazyConsensus = true
+		if coVoteGroupID != "" {
+			proposalTx.Proposal.CoVoteGroups = []types.ProposalVoteGroup{{GroupID: coVoteGroupID}}
+		}
+		proposalTx.Signature = govutil.SignProposal(chainID, "secret1", proposalTx.Proposal)
+		return gov.RunTxParsed(store, base.CallContext{}, proposalTx)
+	}
+
+	if res := propose("my_proposal_id", "my_group_id", ""); res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected lazy consensus to fail without the group opting in", res.Code, res.Log)
+	}
+	if res := propose("my_proposal_id", "lazy_group_id", "my_group_id"); res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected lazy consensus to fail without the co-voting group opting in", res.Code, res.Log)
+	}
+	if res := propose("my_proposal_id", "lazy_group_id", ""); !res.IsOK() {
+		t.Error("Expected lazy consensus to pass with the group opting in", res.Log)
+	}
+}
+
+func TestEarlyTermination(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2", "secret3"})
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 100,
+				&types.TextProposalInfo{Text: "pass"}),
+			govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 100,
+				&types.TextProposalInfo{Text: "fail"}),
+			govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 100,
+				&types.TextProposalInfo{Text: "open"}),
+			govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes),
+			govutil.VoteTx(chainID, "secret2", 1, "1", types.VoteValueYes),
+			govutil.VoteTx(chainID, "secret1", 1, "2", types.VoteValueNo),
+			govutil.VoteTx(chainID, "secret2", 1, "2", types.VoteValueAbstain),
+			govutil.VoteTx(chainID, "secret1", 1, "3", types.VoteValueYes),
+		},
+	})
+
+	if cProposal, ok := gov.GetClosedProposal(store, "1"); !ok || !cProposal.Passed {
+		t.Error("Expected proposal with a yes majority to pass early")
+	}
+	if cProposal, ok := gov.GetClosedProposal(store, "2"); !ok || cProposal.Passed {
+		t.Error("Expected proposal that can't reach a majority to fail early")
+	}
+	if _, ok := gov.GetActiveProposal(store, "3"); !ok {
+		t.Error("Expected undecided proposal to stay active")
+	}
+}
+
+func TestBicameralProposal(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "house_id", []string{"secret1", "secret2"})
+	setupGroup(gov, store, "senate_id", []string{"secret2", "secret3", "secret4"})
+
+	newProposalTx := func(title string) *types.ProposalTx {
+		proposal := types.Proposal{
+			Title:       title,
+			VoteGroupID: "house_id",
+			StartHeight: 1,
+			EndHeight:   100,
+			Info:        &types.TextProposalInfo{Text: title},
+			CoVoteGroups: []types.ProposalVoteGroup{
+				{GroupID: "senate_id", Threshold: types.Fraction{Numerator: 2, Denominator: 3}},
+			},
+		}
+		return &types.ProposalTx{
+			EntityAddr: govutil.EntityAddr("secret1"),
+			Proposal:   proposal,
+			Signature:  govutil.SignProposal(chainID, "secret1", proposal),
+		}
+	}
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			newProposalTx("pass_id"),
+			govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes),
+			govutil.VoteTx(chainID, "secret2", 1, "1", types.VoteValueYes),
+			govutil.GroupVoteTx(chainID, "secret2", 1, "1", "senate_id", types.VoteValueYes),
+			govutil.GroupVoteTx(chainID, "secret3", 1, "1", "senate_id", types.VoteValueYes),
+			newProposalTx("fail_id"),
+			govutil.VoteTx(chainID, "secret1", 1, "2", types.VoteValueYes),
+			govutil.VoteTx(chainID, "secret2", 1, "2", types.VoteValueYes),
+			govutil.GroupVoteTx(chainID, "secret3", 1, "2", "senate_id", types.VoteValueNo),
+			govutil.GroupVoteTx(chainID, "secret4", 1, "2", "senate_id", types.VoteValueNo),
+		},
+	})
+
+	// Votes are per group
+	res := gov.CheckTxParsed(store, base.CallContext{}, govutil.GroupVoteTx(chainID, "secret1", 1, "1", "senate_id", types.VoteValueYes))
+	if res.Code != tmsp.CodeType_GovInvalidMember {
+		t.Error("Expected vote in a group of which the voter is not a member to fail", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.GroupVoteTx(chainID, "secret2", 1, "1", "other_id", types.VoteValueYes))
+	if res.Code != tmsp.CodeType_GovInvalidVote {
+		t.Error("Expected vote in a group that doesn't vote on the proposal to fail", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.GroupVoteTx(chainID, "secret2", 1, "1", "senate_id", types.VoteValueNo))
+	if res.Code != tmsp.CodeType_GovDuplicateVote {
+		t.Error("Expected duplicate vote in a group to fail", res.Code, res.Log)
+	}
+
+	// 2 of 3 doesn't exceed the senate's 2/3 threshold yet
+	if _, ok := gov.GetActiveProposal(store, "1"); !ok {
+		t.Error("Expected proposal approved by only one group to stay active")
+	}
+	// A single group rejecting decides the proposal
+	if cProposal, ok := gov.GetClosedProposal(store, "2"); !ok || cProposal.Passed {
+		t.Error("Expected proposal rejected by one group to fail early")
+	}
+
+	runBlocks(t, gov, store, 2, 2, map[uint64][]types.Tx{
+		2: []types.Tx{
+			govutil.GroupVoteTx(chainID, "secret4", 2, "1", "senate_id", types.VoteValueYes),
+		},
+	})
+
+	cProposal, ok := gov.GetClosedProposal(store, "1")
+	if !ok || !cProposal.Passed {
+		t.Fatal("Expected proposal approved by every group to pass")
+	}
+	if cProposal.Tally.Yes != 2 || len(cProposal.CoTallies) != 1 ||
+		cProposal.CoTallies[0].Tally.Yes != 3 || !cProposal.CoTallies[0].Passed {
+		t.Error("Got wrong tallies", cProposal.Tally, cProposal.CoTallies)
+	}
+}
+
+func TestVeto(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	gov.SetOption(store, "params", `{"veto_period":5}`)
+	setupGroup(gov, store, "parent_id", []string{"secret1", "secret2", "secret3"})
+	setupGroup(gov, store, "child_id", []string{"secret4"})
+	childGroup, _ := gov.GetGroup(store, "child_id")
+	childGroup.ParentID = "parent_id"
+	gov.SetGroup(store, childGroup)
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			govutil.ProposalTx(chainID, "secret4", "child_id", 1, 10,
+				&types.TextProposalInfo{Text: "vetoed"}),
+			govutil.ProposalTx(chainID, "secret4", "child_id", 1, 10,
+				&types.TextProposalInfo{Text: "kept"}),
+			govutil.VoteTx(chainID, "secret4", 1, "1", types.VoteValueYes),
+			govutil.VoteTx(chainID, "secret4", 1, "2", types.VoteValueYes),
+		},
+	})
+
+	cProposal, ok := gov.GetClosedProposal(store, "1")
+	if !ok || !cProposal.Passed || cProposal.Executed || cProposal.ExecuteHeight != 6 {
+		t.Fatal("Expected passed proposal to await execution", cProposal)
+	}
+
+	// The signers must pass the parent's threshold
+	res := gov.CheckTxParsed(store, base.CallContext{}, govutil.VetoTx(chainID, []string{"secret1"}, "1"))
+	if res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected veto with insufficient power to fail", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.VetoTx(chainID, []string{"secret1", "secret1"}, "1"))
+	if res.Code != types.CodeType_GovInvalidVeto {
+		t.Error("Expected veto with duplicate signers to fail", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.VetoTx(chainID, []string{"secret4"}, "1"))
+	if res.Code != tmsp.CodeType_GovInvalidMember {
+		t.Error("Expected veto by a non-member to fail", res.Code, res.Log)
+	}
+
+	runBlocks(t, gov, store, 2, 6, map[uint64][]types.Tx{
+		2: []types.Tx{
+			govutil.VetoTx(chainID, []string{"secret1", "secret2"}, "1"),
+		},
+	})
+
+	if cProposal, _ := gov.GetClosedProposal(store, "1"); !cProposal.Vetoed || cProposal.Executed {
+		t.Error("Expected vetoed proposal not to execute", cProposal.Log)
+	}
+	if cProposal, _ := gov.GetClosedProposal(store, "2"); !cProposal.Executed {
+		t.Error("Expected proposal to execute after the veto period", cProposal.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.VetoTx(chainID, []string{"secret1", "secret2"}, "2"))
+	if res.Code != types.CodeType_GovInvalidVeto {
+		t.Error("Expected veto after execution to fail", res.Code, res.Log)
+	}
+}
+
+func TestExecutionDelay(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1"})
+
+	log := gov.SetOption(store, "params", `{"execution_delays":[{"info_type":1,"delay":3},{"info_type":1,"delay":5}]}`)
+	if log == "Success" {
+		t.Error("Expected duplicate execution delays to fail")
+	}
+	log = gov.SetOption(store, "params", `{"execution_delays":[{"info_type":1,"delay":3}]}`)
+	if log != "Success" {
+		t.Fatal("Expected execution delays to be set", log)
+	}
+
+	runBlocks(t, gov, store, 1, 3, map[uint64][]types.Tx{
+		1: []types.Tx{
+			govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 10,
+				&types.GroupCreateProposalInfo{
+					NewGroupID: "new_group_id",
+					Members:    govutil.Members([]string{"secret1"}, 1),
+				},
+			),
+			govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes),
+		},
+	})
+
+	if _, ok := gov.GetGroup(store, "new_group_id"); ok {
+		t.Error("Expected group not to be created before the delay is over")
+	}
+	res := gov.QueryParsed(store, &types.PendingExecutionsQuery{})
+	var pending []types.PendingExecution
+	if err := wire.ReadBinaryBytes(res.Data, &pending); err != nil {
+		t.Fatal(err)
+	}
+	if len(pending) != 1 || pending[0].Height != 4 || pending[0].ProposalID != "1" {
+		t.Error("Got wrong pending executions", pending)
+	}
+
+	runBlocks(t, gov, store, 4, 4, nil)
+
+	if _, ok := gov.GetGroup(store, "new_group_id"); !ok {
+		t.Error("Expected group to be created once the delay is over")
+	}
+	if len(gov.GetPendingExecutionHeights(store)) != 0 {
+		t.Error("Expected no more pending executions")
+	}
+}
+
+func TestEmergencyProposal(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	gov.SetOption(store, "params", `{"execution_delays":[{"info_type":17,"delay":50}],
+		"min_voting_period":20,"emergency_min_voting_period":10}`)
+	secrets := []string{"secret1", "secret2", "secret3", "secret4"}
+	setupGroup(gov, store, types.AdminGroupID, secrets)
+	setupGroup(gov, store, "my_group_id", secrets)
+
+	emergencyTx := func(title string, voteGroupID string, end uint64) *types.ProposalTx {
+		proposal := types.Proposal{
+			Title:       title,
+			VoteGroupID: voteGroupID,
+			StartHeight: 1,
+			EndHeight:   end,
+			Info:        &types.TextProposalInfo{Text: "patch"},
+			Emergency:   true,
+		}
+		return &types.ProposalTx{
+			EntityAddr: govutil.EntityAddr("secret1"),
+			Proposal:   proposal,
+			Signature:  govutil.SignProposal(chainID, "secret1", proposal),
+		}
+	}
+
+	gov.BeginBlock(store, 1)
+	res := gov.CheckTxParsed(store, base.CallContext{}, emergencyTx("my_proposal_id", "my_group_id", 20))
+	if res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected emergency proposal outside admin group to fail", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, emergencyTx("my_proposal_id", types.AdminGroupID, 5))
+	if res.Code != types.CodeType_GovInvalidVotingPeriod {
+		t.Error("Expected emergency proposal with a short voting period to fail", res.Code, res.Log)
+	}
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			emergencyTx("my_proposal_id", types.AdminGroupID, 11),
+			govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes),
+			govutil.VoteTx(chainID, "secret2", 1, "1", types.VoteValueYes),
+			govutil.VoteTx(chainID, "secret3", 1, "1", types.VoteValueYes),
+		},
+	})
+
+	// 3 of 4 doesn't exceed the emergency threshold
+	if _, ok := gov.GetActiveProposal(store, "1"); !ok {
+		t.Error("Expected emergency proposal to need more than three quarters")
+	}
+
+	runBlocks(t, gov, store, 2, 2, map[uint64][]types.Tx{
+		2: []types.Tx{
+			govutil.VoteTx(chainID, "secret4", 2, "1", types.VoteValueYes),
+		},
+	})
+
+	cProposal, ok := gov.GetClosedProposal(store, "1")
+	if !ok || !cProposal.Passed || !cProposal.Executed {
+		t.Error("Expected emergency proposal to pass and execute without delay", cProposal)
+	}
+}
+
+func TestVotingPeriod(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	gov.SetOption(store, "params", `{"min_voting_period":10,"max_voting_period":100,"max_start_delay":50}`)
+	setupGroup(gov, store, "my_group_id", []string{"secret1"})
+	gov.BeginBlock(store, 20)
+
+	cases := []struct {
+		start uint64
+		end   uint64
+		ok    bool
+	}{
+		{20, 30, true},
+		{70, 170, true},
+		{19, 30, false},  // Starts in the past
+		{71, 90, false},  // Starts too late
+		{30, 25, false},  // Ends before it starts
+		{20, 29, false},  // Too short
+		{20, 121, false}, // Too long
+	}
+	for _, c := range cases {
+		res := gov.CheckTxParsed(store, base.CallContext{}, govutil.ProposalTx(chainID, "secret1",
+			"my_group_id", c.start, c.end, &types.TextProposalInfo{Text: "hello"}))
+		if c.ok && !res.IsOK() {
+			t.Error("Expected voting period to be valid", c.start, c.end, res.Log)
+		}
+		if !c.ok && res.Code != types.CodeType_GovInvalidVotingPeriod {
+			t.Error("Expected voting period to be invalid", c.start, c.end, res.Code, res.Log)
+		}
+	}
+}
+
+func TestVotingTime(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2", "secret3"})
+
+	proposalTx := govutil.ProposalTx(chainID, "secret1", "my_group_id", 1, 0,
+		&types.TextProposalInfo{Text: "hello"})
+	proposalTx.Proposal.EndTime = 1035
+	proposalTx.Signature = govutil.SignProposal(chainID, "secret1", proposalTx.Proposal)
+
+	// Block times are 10 seconds apart
+	beginBlock := func(height uint64) {
+		gov.SetBlockTime(1000 + height*10)
+		gov.BeginBlock(store, height)
+	}
+	runTx := func(tx types.Tx) {
+		if res := gov.RunTxParsed(store, base.CallContext{}, tx); !res.IsOK() {
+			t.Fatal("Expected tx to pass", res.Log)
+		}
+	}
+
+	gov.BeginBlock(store, 1)
+	if res := gov.CheckTxParsed(store, base.CallContext{}, proposalTx); res.Code != types.CodeType_GovInvalidVotingPeriod {
+		t.Error("Expected voting times without a block time to fail", res.Code, res.Log)
+	}
+
+	beginBlock(1)
+	runTx(proposalTx)
+	runTx(govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes))
+	gov.EndBlock(store, 1)
+	beginBlock(3)
+	runTx(govutil.VoteTx(chainID, "secret2", 3, "1", types.VoteValueAbstain))
+	gov.EndBlock(store, 3)
+
+	if _, ok := gov.GetActiveProposal(store, "1"); !ok {
+		t.Fatal("Expected proposal to be active before its end time")
+	}
+
+	// Block time 1040 is past the end time
+	beginBlock(4)
+	res := gov.CheckTxParsed(store, base.CallContext{}, govutil.VoteTx(chainID, "secret3", 4, "1", types.VoteValueYes))
+	if res.Code != tmsp.CodeType_GovInvalidVote {
+		t.Error("Expected vote after the end time to fail", res.Code, res.Log)
+	}
+	gov.EndBlock(store, 4)
+
+	cProposal, ok := gov.GetClosedProposal(store, "1")
+	if !ok || cProposal.Passed || cProposal.Tally.Yes != 1 || cProposal.CloseHeight != 4 {
+		t.Error("Expected proposal to close at its end time", cProposal)
+	}
+}
+
+func TestProposerGroup(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "steering_id", []string{"secret1"})
+	setupGroup(gov, store, "community_id", []string{"secret1", "secret2", "secret3"})
+	setupGroup(gov, store, "other_id", []string{"secret4"})
+	community, _ := gov.GetGroup(store, "community_id")
+	community.Policy.ProposerGroupID = "steering_id"
+	community.Policy.ProposalDeposit = base.Coins{{Denom: "mycoin", Amount: 10}}
+	gov.SetGroup(store, community)
+
+	propose := func(secret string, text string, voteGroupID string, coins base.Coins) tmsp.Result {
+		return gov.RunTxParsed(store, base.CallContext{Coins: coins},
+			govutil.ProposalTx(chainID, secret, voteGroupID, 0, 10,
+				&types.TextProposalInfo{Text: text}))
+	}
+
+	if res := propose("secret1", "steering_proposal_id", "community_id", nil); !res.IsOK() {
+		t.Error("Expected proposer group member to propose", res.Log)
+	}
+	if res := propose("secret2", "member_proposal_id", "community_id", nil); res.Code != tmsp.CodeType_InsufficientFunds {
+		t.Error("Expected vote group member outside the proposer group to need a deposit", res.Code, res.Log)
+	}
+	if res := propose("secret4", "external_proposal_id", "community_id", base.Coins{{Denom: "mycoin", Amount: 5}}); res.Code != tmsp.CodeType_InsufficientFunds {
+		t.Error("Expected insufficient deposit to fail", res.Code, res.Log)
+	}
+	externalTx := govutil.ProposalTx(chainID, "secret4", "community_id", 0, 10,
+		&types.TextProposalInfo{Text: "hello"})
+	if res := gov.CheckTxParsed(store, base.CallContext{Coins: base.Coins{{Denom: "mycoin", Amount: 10}}}, externalTx); !res.IsOK() {
+		t.Error("Expected external proposal with a deposit to pass CheckTx", res.Log)
+	}
+	if res := propose("secret4", "external_proposal_id", "community_id", base.Coins{{Denom: "mycoin", Amount: 10}}); !res.IsOK() {
+		t.Error("Expected external proposal with a deposit to pass", res.Log)
+	}
+	aProposal, ok := gov.GetActiveProposal(store, "2")
+	if !ok || string(aProposal.Proposer) != string(govutil.EntityAddr("secret4")) ||
+		len(aProposal.Deposit) != 1 || aProposal.Deposit[0].Amount != 10 {
+		t.Error("Expected proposer and deposit to be recorded", aProposal)
+	}
+	// Groups without a deposit only take proposals from members
+	if res := propose("secret4", "steering_proposal_id2", "steering_id", base.Coins{{Denom: "mycoin", Amount: 10}}); res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected external proposal without a group deposit to fail", res.Code, res.Log)
+	}
+
+	// The deposit of a rejected proposal is forfeited
+	runBlocks(t, gov, store, 1, 10, nil)
+	var settlements []types.DepositSettlement
+	res := gov.QueryParsed(store, &types.DepositSettlementsQuery{Height: 10})
+	if err := wire.ReadBinaryBytes(res.Data, &settlements); err != nil {
+		t.Fatal(err)
+	}
+	if len(settlements) != 1 || settlements[0].ProposalID != "2" || settlements[0].Refunded ||
+		string(settlements[0].Proposer) != string(govutil.EntityAddr("secret4")) {
+		t.Error("Got wrong deposit settlements", settlements)
+	}
+	if cProposal, ok := gov.GetClosedProposal(store, "2"); !ok || cProposal.DepositRefunded {
+		t.Error("Expected deposit of the rejected proposal to be forfeited", cProposal)
+	}
+}
+
+func TestProposalRateLimits(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	gov.SetOption(store, "params", `{"max_active_proposals_per_proposer":2,
+		"max_active_proposals_per_group":3,"rejection_cooldown":5}`)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2"})
+
+	proposalTx := func(secret string, text string, height uint64) *types.ProposalTx {
+		return govutil.ProposalTx(chainID, secret, "my_group_id", height, height+10,
+			&types.TextProposalInfo{Text: text})
+	}
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			proposalTx("secret1", "proposal1", 1),
+			proposalTx("secret1", "proposal2", 1),
+			proposalTx("secret2", "proposal3", 1),
+		},
+	})
+
+	res := gov.CheckTxParsed(store, base.CallContext{}, proposalTx("secret1", "proposal4", 1))
+	if res.Code != types.CodeType_GovRateLimited {
+		t.Error("Expected proposer limit to be enforced", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, proposalTx("secret2", "proposal4", 1))
+	if res.Code != types.CodeType_GovRateLimited {
+		t.Error("Expected group limit to be enforced", res.Code, res.Log)
+	}
+
+	// Rejecting proposal1 frees a slot, but starts the cooldown
+	runBlocks(t, gov, store, 2, 2, map[uint64][]types.Tx{
+		2: []types.Tx{
+			govutil.VoteTx(chainID, "secret1", 2, "1", types.VoteValueNo),
+			govutil.VoteTx(chainID, "secret2", 2, "1", types.VoteValueNo),
+		},
+	})
+	if cProposal, ok := gov.GetClosedProposal(store, "1"); !ok || cProposal.Passed {
+		t.Fatal("Expected proposal1 to be rejected")
+	}
+
+	gov.BeginBlock(store, 6)
+	res = gov.CheckTxParsed(store, base.CallContext{}, proposalTx("secret1", "proposal4", 6))
+	if res.Code != types.CodeType_GovRateLimited {
+		t.Error("Expected cooldown after a rejection to be enforced", res.Code, res.Log)
+	}
+	gov.BeginBlock(store, 7)
+	res = gov.CheckTxParsed(store, base.CallContext{}, proposalTx("secret1", "proposal4", 7))
+	if !res.IsOK() {
+		t.Error("Expected proposal after the cooldown to pass", res.Log)
+	}
+
+	// A rejection doesn't affect the proposer's proposals that were already accepted
+	runBlocks(t, gov, store, 7, 8, map[uint64][]types.Tx{
+		7: []types.Tx{proposalTx("secret2", "proposal5", 10)},
+		8: []types.Tx{
+			govutil.VoteTx(chainID, "secret1", 8, "3", types.VoteValueNo),
+			govutil.VoteTx(chainID, "secret2", 8, "3", types.VoteValueNo),
+		},
+	})
+	if _, ok := gov.GetActiveProposal(store, "4"); !ok {
+		t.Error("Expected proposal starting during the cooldown to stay open")
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, proposalTx("secret2", "proposal6", 10))
+	if res.Code != types.CodeType_GovRateLimited {
+		t.Error("Expected new proposal during the cooldown to be rejected", res.Code, res.Log)
+	}
+}
+
+func TestProposalMetadata(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1"})
+	gov.BeginBlock(store, 1)
+
+	long := strings.Repeat("x", gm.MaxDescriptionLength+1)
+	cases := []struct {
+		title       string
+		description string
+		links       []string
+		contentHash []byte
+		text        string
+		ok          bool
+	}{
+		{"my_proposal", "details", []string{"https://example.com/doc"}, make([]byte, 32), "hello", true},
+		{"", "", nil, nil, "hello", true},                                                 // Title is optional
+		{long[:gm.MaxTitleLength+1], "", nil, nil, "hello", false},                        // Title too long
+		{"my_proposal", long, nil, nil, "hello", false},                                   // Description too long
+		{"my_proposal", "", []string{""}, nil, "hello", false},                            // Empty link
+		{"my_proposal", "", nil, make([]byte, gm.MaxContentHashLength+1), "hello", false}, // Hash too long
+		{"my_proposal", "", nil, nil, long[:gm.MaxTextLength+1], false},                   // Text too long
+	}
+	for i, c := range cases {
+		proposal := types.Proposal{
+			Title:       c.title,
+			Description: c.description,
+			Links:       c.links,
+			ContentHash: c.contentHash,
+			VoteGroupID: "my_group_id",
+			StartHeight: 1,
+			EndHeight:   10,
+			Info:        &types.TextProposalInfo{Text: c.text},
+		}
+		res := gov.CheckTxParsed(store, base.CallContext{}, &types.ProposalTx{
+			EntityAddr: govutil.EntityAddr("secret1"),
+			Proposal:   proposal,
+			Signature:  govutil.SignProposal(chainID, "secret1", proposal),
+		})
+		if c.ok && !res.IsOK() {
+			t.Error("Expected metadata to be valid", i, res.Log)
+		}
+		if !c.ok && res.Code != tmsp.CodeType_EncodingError {
+			t.Error("Expected metadata to be invalid", i, res.Code, res.Log)
+		}
+	}
+}
+
+func TestEntityUpdate(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2"})
+
+	profile := types.EntityProfile{
+		Name:    "Alice",
+		Contact: "alice@example.com",
+		Website: "https://example.com",
+		KeyType: types.KeyTypeEd25519,
+	}
+	updateTx := govutil.EntityUpdateTx(chainID, "secret1", 1, profile)
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{updateTx},
+	})
+
+	res := gov.QueryParsed(store, &types.EntityQuery{EntityAddr: govutil.EntityAddr("secret1")})
+	if !res.IsOK() {
+		t.Fatal("Expected entity query to succeed", res.Log)
+	}
+	var entity types.Entity
+	if err := wire.ReadBinaryBytes(res.Data, &entity); err != nil {
+		t.Fatal(err)
+	}
+	if entity.Version != 1 || entity.Profile != profile {
+		t.Error("Expected entity profile to be updated", entity.Version, entity.Profile)
+	}
+
+	// The update can't be replayed
+	res = gov.CheckTxParsed(store, base.CallContext{}, updateTx)
+	if res.Code != types.CodeType_GovInvalidEntityVersion {
+		t.Error("Expected replayed update to be rejected", res.Code, res.Log)
+	}
+	// Only the entity itself can update its profile
+	forgedTx := govutil.EntityUpdateTx(chainID, "secret2", 1, profile)
+	forgedTx.EntityAddr = govutil.EntityAddr("secret1")
+	forgedTx.NextVersion = 2
+	res = gov.CheckTxParsed(store, base.CallContext{}, forgedTx)
+	if res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected update signed by another entity to be rejected", res.Code, res.Log)
+	}
+	// The profile must be within limits
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.EntityUpdateTx(chainID, "secret1", 2,
+		types.EntityProfile{Name: strings.Repeat("x", gm.MaxEntityNameLength+1)}))
+	if res.Code != tmsp.CodeType_EncodingError {
+		t.Error("Expected long name to be rejected", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.EntityUpdateTx(chainID, "secret1", 2,
+		types.EntityProfile{KeyType: "rsa"}))
+	if res.Code != tmsp.CodeType_EncodingError {
+		t.Error("Expected unknown key type to be rejected", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.EntityUpdateTx(chainID, "secret1", 2,
+		types.EntityProfile{KeyType: types.KeyTypeSecp256k1}))
+	if res.Code != tmsp.CodeType_EncodingError {
+		t.Error("Expected key type of another pubkey to be rejected", res.Code, res.Log)
+	}
+	// Entities with other key types can tag their own
+	privKey := crypto.GenPrivKeySecp256k1FromSecret([]byte("secret3"))
+	gov.SetEntity(store, &types.Entity{Addr: []byte("secp256k1_entity"), PubKey: privKey.PubKey()})
+	secpUpdateTx := func(keyType string) *types.EntityUpdateTx {
+		tx := &types.EntityUpdateTx{
+			EntityAddr:  []byte("secp256k1_entity"),
+			NextVersion: 1,
+			Profile:     types.EntityProfile{KeyType: keyType},
+		}
+		tx.Signature = privKey.Sign(tx.SignBytes(chainID))
+		return tx
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, secpUpdateTx(types.KeyTypeEd25519))
+	if res.Code != tmsp.CodeType_EncodingError {
+		t.Error("Expected key type of another pubkey to be rejected", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, secpUpdateTx(types.KeyTypeSecp256k1))
+	if !res.IsOK() {
+		t.Error("Expected secp256k1 entity to tag its key type", res.Log)
+	}
+}
+
+func TestGroupCharter(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "parent_id", []string{"secret1"})
+	setupGroup(gov, store, "child_id", []string{"secret2"})
+	childGroup, _ := gov.GetGroup(store, "child_id")
+	childGroup.ParentID = "parent_id"
+	gov.SetGroup(store, childGroup)
+
+	charter := types.GroupCharter{
+		Name:        "Treasury",
+		Description: "Manages the treasury",
+		CharterHash: make([]byte, 32),
+	}
+	charterInfo := &types.GroupCharterProposalInfo{
+		UpdateGroupID: "child_id",
+		NextVersion:   1,
+		Charter:       charter,
+	}
+
+	// Only the parent group can change the charter
+	gov.BeginBlock(store, 1)
+	res := gov.CheckTxParsed(store, base.CallContext{}, govutil.ProposalTx(chainID, "secret2",
+		"child_id", 1, 10, charterInfo))
+	if res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected charter proposal by the group itself to fail", res.Code, res.Log)
+	}
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.ProposalTx(chainID, "secret1", "parent_id", 1, 10,
+		&types.GroupCharterProposalInfo{
+			UpdateGroupID: "child_id",
+			NextVersion:   1,
+			Charter:       types.GroupCharter{Name: strings.Repeat("x", gm.MaxGroupNameLength+1)},
+		}))
+	if res.Code != tmsp.CodeType_EncodingError {
+		t.Error("Expected long group name to be rejected", res.Code, res.Log)
+	}
+
+	runBlocks(t, gov, store, 1, 1, map[uint64][]types.Tx{
+		1: []types.Tx{
+			govutil.ProposalTx(chainID, "secret1", "parent_id", 1, 10, charterInfo),
+			govutil.VoteTx(chainID, "secret1", 1, "1", types.VoteValueYes),
+		},
+	})
+
+	childGroup, _ = gov.GetGroup(store, "child_id")
+	if childGroup.Version != 1 || childGroup.Charter.Name != charter.Name ||
+		childGroup.Charter.Description != charter.Description ||
+		len(childGroup.Charter.CharterHash) != 32 {
+		t.Error("Expected group charter to be updated", childGroup.Version, childGroup.Charter)
+	}
+}
+
+func TestGroupDelete(t *testing.T) {
+
+	setup := func() (*gm.Governmint, base.KVStore) {
+		gov := gm.NewGovernmint()
+		store := base.NewMemKVStore()
+		gov.SetOption(store, "chain_id", chainID)
+		setupGroup(gov, store, "parent_id", []string{"secret1"})
+		setupGroup(gov, store, "child_id", []string{"secret2"})
+		setupGroup(gov, store, "grandchild_id", []string{"secret3"})
+		childGroup, _ := gov.GetGroup(store, "child_id")
+		childGroup.ParentID = "parent_id"
+		gov.SetGroup(store, childGroup)
+		grandchildGroup, _ := gov.GetGroup(store, "grandchild_id")
+		grandchildGroup.ParentID = "child_id"
+		gov.SetGroup(store, grandchildGroup)
+		return gov, store
+	}
+	deleteTxs := func(cascade bool) map[uint64][]types.Tx {
+		return map[uint64][]types.Tx{
+			1: []types.Tx{
+				govutil.ProposalTx(chainID, "secret2", "child_id", 1, 10,
+					&types.TextProposalInfo{Text: "child"}),
+				govutil.ProposalTx(chainID, "secret3", "grandchild_id", 1, 10,
+					&types.TextProposalInfo{Text: "grandchild"}),
+			},
+			2: []types.Tx{
+				govutil.ProposalTx(chainID, "secret1", "parent_id", 2, 10,
+					&types.GroupDeleteProposalInfo{DeleteGroupID: "child_id", Cascade: cascade}),
+				govutil.VoteTx(chainID, "secret1", 2, "3", types.VoteValueYes),
+			},
+		}
+	}
+
+	// Without cascading, the children are given the deleted group's parent
+	gov, store := setup()
+	gov.SetVoteCredits(store, "child_id", govutil.EntityAddr("secret4"), &types.VoteCredits{Locked: 1})
+	gov.BeginBlock(store, 1)
+	res := gov.CheckTxParsed(store, base.CallContext{}, govutil.ProposalTx(chainID, "secret3", "grandchild_id", 1, 10,
+		&types.GroupDeleteProposalInfo{DeleteGroupID: "child_id"}))
+	if res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected delete by a non-parent group to fail", res.Code, res.Log)
+	}
+	runBlocks(t, gov, store, 1, 2, deleteTxs(false))
+
+	if cProposal, ok := gov.GetClosedProposal(store, "3"); !ok || !cProposal.Executed {
+		t.Fatal("Expected delete proposal to be executed", cProposal)
+	}
+	if _, ok := gov.GetGroup(store, "child_id"); ok {
+		t.Error("Expected child group to be deleted")
+	}
+	if group, ok := gov.GetGroup(store, "grandchild_id"); !ok || group.ParentID != "parent_id" || group.Version != 1 {
+		t.Error("Expected grandchild group to be reparented at the next version", group)
+	}
+	if cProposal, ok := gov.GetClosedProposal(store, "1"); !ok || !cProposal.Canceled {
+		t.Error("Expected proposal of the deleted group to be canceled")
+	}
+	if _, ok := gov.GetActiveProposal(store, "2"); !ok {
+		t.Error("Expected proposal of the grandchild group to stay active")
+	}
+	if _, ok := gov.GetVoteCredits(store, "child_id", govutil.EntityAddr("secret4")); ok {
+		t.Error("Expected vote credits of a former member to be removed")
+	}
+
+	// With cascading, the descendants are deleted too
+	gov, store = setup()
+	runBlocks(t, gov, store, 1, 2, deleteTxs(true))
+
+	if _, ok := gov.GetGroup(store, "grandchild_id"); ok {
+		t.Error("Expected grandchild group to be deleted")
+	}
+	if cProposal, ok := gov.GetClosedProposal(store, "2"); !ok || !cProposal.Canceled {
+		t.Error("Expected proposal of the deleted grandchild group to be canceled")
+	}
+	if ids := gov.GetGroupIDs(store); len(ids) != 1 || ids[0] != "parent_id" {
+		t.Error("Got wrong group ids", ids)
+	}
+
+	// Passed proposals of the deleted group's children may be vetoed by their new parent
+	gov, store = setup()
+	gov.SetOption(store, "params", `{"veto_period":5}`)
+	runBlocks(t, gov, store, 1, 2, map[uint64][]types.Tx{
+		1: []types.Tx{
+			govutil.ProposalTx(chainID, "secret3", "grandchild_id", 1, 10,
+				&types.TextProposalInfo{Text: "grandchild"}),
+			govutil.VoteTx(chainID, "secret3", 1, "1", types.VoteValueYes),
+		},
+		2: []types.Tx{
+			govutil.ProposalTx(chainID, "secret1", "parent_id", 2, 10,
+				&types.GroupDeleteProposalInfo{DeleteGroupID: "child_id"}),
+			govutil.VoteTx(chainID, "secret1", 2, "2", types.VoteValueYes),
+		},
+	})
+	if cProposal, ok := gov.GetClosedProposal(store, "1"); !ok || cProposal.Canceled || cProposal.VetoGroupID != "parent_id" {
+		t.Error("Expected pending proposal to be vetoable by the new parent", cProposal)
+	}
+	runBlocks(t, gov, store, 3, 6, nil)
+	if cProposal, ok := gov.GetClosedProposal(store, "1"); !ok || !cProposal.Executed {
+		t.Error("Expected pending proposal of the reparented group to be executed", cProposal)
+	}
+
+	// A group can't be deleted while another group proposes through it
+	gov, store = setup()
+	grandchildGroup, _ := gov.GetGroup(store, "grandchild_id")
+	grandchildGroup.Policy.ProposerGroupID = "child_id"
+	gov.SetGroup(store, grandchildGroup)
+	gov.BeginBlock(store, 1)
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.ProposalTx(chainID, "secret1", "parent_id", 1, 10,
+		&types.GroupDeleteProposalInfo{DeleteGroupID: "child_id"}))
+	if res.Code != tmsp.CodeType_Unauthorized {
+		t.Error("Expected delete of a proposer group to fail", res.Code, res.Log)
+	}
+}
+
+func TestMemberExpiry(t *testing.T) {
+
+	gov := gm.NewGovernmint()
+	store := base.NewMemKVStore()
+	gov.SetOption(store, "chain_id", chainID)
+	setupGroup(gov, store, "my_group_id", []string{"secret1", "secret2", "secret3"})
+	group, _ := gov.GetGroup(store, "my_group_id")
+	group.Members[0].ExpiresHeight = 5
+	group.Members[1].ExpiresHeight = 20
+	gov.SetGroup(store, group)
+
+	gov.BeginBlock(store, 1)
+	var expirations []types.MemberExpiration
+	res := gov.QueryParsed(store, &types.MemberExpirationsQuery{})
+	if err := wire.ReadBinaryBytes(res.Data, &expirations); err != nil {
+		t.Fatal(err)
+	}
+	if len(expirations) != 2 || expirations[0].ExpiresHeight != 5 || expirations[1].ExpiresHeight != 20 {
+		t.Error("Got wrong member expirations", expirations)
+	}
+	res = gov.QueryParsed(store, &types.MemberExpirationsQuery{Within: 10})
+	if err := wire.ReadBinaryBytes(res.Data, &expirations); err != nil {
+		t.Fatal(err)
+	}
+	if len(expirations) != 1 || expirations[0].GroupID != "my_group_id" ||
+		string(expirations[0].EntityAddr) != string(govutil.EntityAddr("secret1")) {
+		t.Error("Got wrong member expirations within 10 blocks", expirations)
+	}
+
+	runBlocks(t, gov, store, 6, 6, map[uint64][]types.Tx{
+		6: []types.Tx{
+			govutil.ProposalTx(chainID, "secret2", "my_group_id", 6, 25,
+				&types.TextProposalInfo{Text: "hello"}),
+			govutil.VoteTx(chainID, "secret2", 6, "1", types.VoteValueYes),
+		},
+	})
+
+	// Expired members can't vote
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.VoteTx(chainID, "secret1", 6, "1", types.VoteValueYes))
+	if res.Code != tmsp.CodeType_GovInvalidMember {
+		t.Error("Expected vote by an expired member to fail", res.Code, res.Log)
+	}
+
+	// Votes of members that expire before the tally don't count
+	runBlocks(t, gov, store, 7, 25, nil)
+	cProposal, ok := gov.GetClosedProposal(store, "1")
+	if !ok || cProposal.Passed || cProposal.Tally.Total != 1 || cProposal.Tally.Yes != 0 {
+		t.Error("Expected expired members to have no voting power", cProposal)
+	}
+
+	// Members can't be added with an expiry that has passed
+	expired := govutil.Members([]string{"secret4"}, 1)
+	expired[0].ExpiresHeight = 25
+	gov.SetEntity(store, &govutil.Entities([]string{"secret4"})[0].Entity)
+	res = gov.CheckTxParsed(store, base.CallContext{}, govutil.ProposalTx(chainID, "secret3", "my_group_id", 25, 30,
+		&types.GroupCreateProposalInfo{NewGroupID: "new_group_id", Members: expired}))
+	if res.Code != tmsp.CodeType_GovInvalidMember {
+		t.Error("Expected member with a past expiry to fail", res.Code, res.Log)
+	}
+}
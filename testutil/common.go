@@ -8,19 +8,19 @@ import (
 
 // TODO move this to gov/testutil package
 
-func SignVote(secret string, vote types.Vote) crypto.Signature {
+func SignVote(chainID string, secret string, vote types.Vote) crypto.Signature {
 	privKey := crypto.GenPrivKeyEd25519FromSecret([]byte(secret))
-	voteSignBytes := vote.SignBytes()
+	voteSignBytes := vote.SignBytes(chainID)
 	return privKey.Sign(voteSignBytes)
 }
 
-func SignProposal(secret string, proposal types.Proposal) crypto.Signature {
+func SignProposal(chainID string, secret string, proposal types.Proposal) crypto.Signature {
 	privKey := crypto.GenPrivKeyEd25519FromSecret([]byte(secret))
-	proposalSignBytes := proposal.SignBytes()
+	proposalSignBytes := proposal.SignBytes(chainID)
 	return privKey.Sign(proposalSignBytes)
 }
 
-func VoteTx(secret string, height uint64,
+func VoteTx(chainID string, secret string, height uint64,
 	proposalID string, value string) *types.VoteTx {
 	vote := types.Vote{
 		Height:     height,
@@ -30,15 +30,90 @@ func VoteTx(secret string, height uint64,
 	}
 	return &types.VoteTx{
 		Vote:      vote,
-		Signature: SignVote(secret, vote),
+		Signature: SignVote(chainID, secret, vote),
 	}
 }
 
-func ProposalTx(secret string, proposalID string, voteGroupID string,
+// Like VoteTx, but votes in one of the proposal's CoVoteGroups.
+func GroupVoteTx(chainID string, secret string, height uint64,
+	proposalID string, groupID string, value string) *types.VoteTx {
+	vote := types.Vote{
+		Height:     height,
+		EntityAddr: EntityAddr(secret),
+		ProposalID: proposalID,
+		Value:      value,
+		GroupID:    groupID,
+	}
+	return &types.VoteTx{
+		Vote:      vote,
+		Signature: SignVote(chainID, secret, vote),
+	}
+}
+
+func SignVoteCommit(chainID string, secret string, commit types.VoteCommit) crypto.Signature {
+	privKey := crypto.GenPrivKeyEd25519FromSecret([]byte(secret))
+	return privKey.Sign(commit.SignBytes(chainID))
+}
+
+// groupID is the proposal's vote group.
+func VoteCommitTx(chainID string, secret string, height uint64,
+	proposalID string, groupID string, value string, salt string) *types.VoteCommitTx {
+	commit := types.VoteCommit{
+		Height:     height,
+		EntityAddr: EntityAddr(secret),
+		ProposalID: proposalID,
+		Hash:       types.VoteCommitHash(chainID, proposalID, groupID, EntityAddr(secret), value, 0, salt),
+	}
+	return &types.VoteCommitTx{
+		Commit:    commit,
+		Signature: SignVoteCommit(chainID, secret, commit),
+	}
+}
+
+func VoteRevealTx(chainID string, secret string, height uint64,
+	proposalID string, value string, salt string) *types.VoteRevealTx {
+	vote := types.Vote{
+		Height:     height,
+		EntityAddr: EntityAddr(secret),
+		ProposalID: proposalID,
+		Value:      value,
+	}
+	return &types.VoteRevealTx{
+		Vote:      vote,
+		Salt:      salt,
+		Signature: SignVote(chainID, secret, vote),
+	}
+}
+
+// Signed by each of secrets.
+func VetoTx(chainID string, secrets []string, proposalID string) *types.VetoTx {
+	tx := &types.VetoTx{ProposalID: proposalID}
+	for _, secret := range secrets {
+		privKey := crypto.GenPrivKeyEd25519FromSecret([]byte(secret))
+		tx.Signatures = append(tx.Signatures, types.VetoSignature{
+			EntityAddr: EntityAddr(secret),
+			Signature:  privKey.Sign(tx.SignBytes(chainID)),
+		})
+	}
+	return tx
+}
+
+func EntityUpdateTx(chainID string, secret string, nextVersion int, profile types.EntityProfile) *types.EntityUpdateTx {
+	privKey := crypto.GenPrivKeyEd25519FromSecret([]byte(secret))
+	tx := &types.EntityUpdateTx{
+		EntityAddr:  EntityAddr(secret),
+		NextVersion: nextVersion,
+		Profile:     profile,
+	}
+	tx.Signature = privKey.Sign(tx.SignBytes(chainID))
+	return tx
+}
+
+// The proposal's ID is assigned by governmint, in order from "1".
+func ProposalTx(chainID string, secret string, voteGroupID string,
 	start uint64, end uint64, info types.ProposalInfo) *types.ProposalTx {
 
 	proposal := types.Proposal{
-		ID:          proposalID,
 		VoteGroupID: voteGroupID,
 		StartHeight: start,
 		EndHeight:   end,
@@ -47,7 +122,7 @@ func ProposalTx(secret string, proposalID string, voteGroupID string,
 	return &types.ProposalTx{
 		EntityAddr: EntityAddr(secret),
 		Proposal:   proposal,
-		Signature:  SignProposal(secret, proposal),
+		Signature:  SignProposal(chainID, secret, proposal),
 	}
 }
 
@@ -0,0 +1,41 @@
+package types
+
+import (
+	. "github.com/tendermint/go-common"
+	tmsp "github.com/tendermint/tmsp/types"
+)
+
+// tmsp reserves 200 ~ 299 for governance.
+// Codes that tmsp doesn't define yet continue from its last Gov* code.
+const (
+	CodeType_GovUnknownTx     = tmsp.CodeType(211)
+	CodeType_GovUnknownQuery  = tmsp.CodeType(212)
+	CodeType_GovCorruptRecord = tmsp.CodeType(213)
+	CodeType_GovInvalidPolicy = tmsp.CodeType(214)
+	CodeType_GovInvalidParams = tmsp.CodeType(215)
+
+	CodeType_GovInvalidGroupVersion  = tmsp.CodeType(216)
+	CodeType_GovUnknownProposalInfo  = tmsp.CodeType(217)
+	CodeType_GovInvalidVeto          = tmsp.CodeType(218)
+	CodeType_GovInvalidVotingPeriod  = tmsp.CodeType(219)
+	CodeType_GovRateLimited          = tmsp.CodeType(220)
+	CodeType_GovInvalidEntityVersion = tmsp.CodeType(221)
+)
+
+// GovError is an error with a code in the governmint codespace.
+type GovError struct {
+	Code tmsp.CodeType
+	Log  string
+}
+
+func NewGovError(code tmsp.CodeType, log string) GovError {
+	return GovError{code, log}
+}
+
+func (err GovError) Error() string {
+	return Fmt("Governmint error %v: %v", err.Code, err.Log)
+}
+
+func (err GovError) Result() tmsp.Result {
+	return tmsp.NewError(err.Code, err.Log)
+}
@@ -1,6 +1,11 @@
 package types
 
 import (
+	"crypto/sha256"
+	"strconv"
+	"strings"
+
+	base "github.com/tendermint/basecoin/types"
 	"github.com/tendermint/go-crypto"
 	"github.com/tendermint/go-wire"
 )
@@ -11,35 +16,138 @@ const (
 )
 
 type Entity struct {
-	Addr   []byte        `json:"addr"`
-	PubKey crypto.PubKey `json:"pub_key"`
+	Addr    []byte        `json:"addr"`
+	PubKey  crypto.PubKey `json:"pub_key"`
+	Version int           `json:"version"` // Incremented by each EntityUpdateTx
+	Profile EntityProfile `json:"profile"`
+}
+
+// Optional information about an entity, for display.
+type EntityProfile struct {
+	Name    string `json:"name"`
+	Contact string `json:"contact"`
+	Website string `json:"website"`
+	KeyType string `json:"key_type"` // The type of the entity's PubKey, or empty
 }
 
+const (
+	KeyTypeEd25519   = "ed25519"
+	KeyTypeSecp256k1 = "secp256k1"
+)
+
 type Group struct {
-	ID       string   `json:"id"`
-	ParentID string   `json:"parent_id"`
-	Version  int      `json:"version"`
-	Members  []Member `json:"members"`
+	ID       string       `json:"id"`
+	ParentID string       `json:"parent_id"`
+	Version  int          `json:"version"`
+	Members  []Member     `json:"members"`
+	Policy   GroupPolicy  `json:"policy"`
+	Charter  GroupCharter `json:"charter"`
+}
+
+// GroupCharter describes a group's purpose.
+// It can only be changed by a GroupCharterProposalInfo voted on by the parent group.
+type GroupCharter struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	CharterHash []byte `json:"charter_hash"` // Hash of the off-chain charter document
+}
+
+// GroupPolicy determines how proposals voted on by a group pass.
+type GroupPolicy struct {
+	// More than VoteThreshold of the group's total voting power must vote yes.
+	// The zero value means a simple majority.
+	VoteThreshold Fraction `json:"vote_threshold"`
+
+	// VotingModeLinear or VotingModeQuadratic, the zero value means linear.
+	// A proposal may override it.
+	VotingMode string `json:"voting_mode"`
+
+	// If set, proposals voted on by this group may use lazy consensus.
+	AllowLazyConsensus bool `json:"allow_lazy_consensus"`
+
+	// Lazy consensus proposals pass unless more than ObjectionThreshold
+	// of the group's total voting power votes no.
+	// The zero value means a third.
+	ObjectionThreshold Fraction `json:"objection_threshold"`
+
+	// If set, members of this group propose instead of the group's own members.
+	ProposerGroupID string `json:"proposer_group_id"`
+
+	// If set, other entities may propose by sending at least these coins
+	// with the proposal tx. See DepositSettlement.
+	ProposalDeposit base.Coins `json:"proposal_deposit"`
+}
+
+const (
+	// Votes weigh the member's VotingPower.
+	VotingModeLinear = "linear"
+
+	// Members lock vote credits on their votes, up to their VotingPower
+	// across all of the group's active proposals.
+	// Votes weigh the square root of the credits.
+	VotingModeQuadratic = "quadratic"
+)
+
+var (
+	DefaultVoteThreshold      = Fraction{1, 2}
+	DefaultObjectionThreshold = Fraction{1, 3}
+)
+
+func (policy GroupPolicy) Threshold() Fraction {
+	if policy.VoteThreshold.Denominator == 0 {
+		return DefaultVoteThreshold
+	}
+	return policy.VoteThreshold
+}
+
+func (policy GroupPolicy) GetObjectionThreshold() Fraction {
+	if policy.ObjectionThreshold.Denominator == 0 {
+		return DefaultObjectionThreshold
+	}
+	return policy.ObjectionThreshold
+}
+
+type Fraction struct {
+	Numerator   uint64 `json:"numerator"`
+	Denominator uint64 `json:"denominator"`
 }
 
 type Member struct {
-	EntityAddr  []byte `json:"entity_addr"`
-	VotingPower uint64 `json:"voting_power"`
+	EntityAddr    []byte `json:"entity_addr"`
+	VotingPower   uint64 `json:"voting_power"`
+	ExpiresHeight uint64 `json:"expires_height"` // 0 means never
 }
 
 func NewMember(entityAddr []byte, votingPower uint64) Member {
-	return Member{entityAddr, votingPower}
+	return Member{EntityAddr: entityAddr, VotingPower: votingPower}
 }
 
+// Expired members have no voting power after ExpiresHeight.
+func (member Member) ExpiredAt(height uint64) bool {
+	return member.ExpiresHeight != 0 && height > member.ExpiresHeight
+}
+
+const (
+	VoteValueYes     = "yes"
+	VoteValueNo      = "no"
+	VoteValueAbstain = "abstain"
+)
+
 type Vote struct {
 	Height     uint64 `json:"height"`
 	EntityAddr []byte `json:"entity_addr"`
 	ProposalID string `json:"proposal_id"`
 	Value      string `json:"value"`
+	Credits    uint64 `json:"credits"`  // Quadratic voting only
+	GroupID    string `json:"group_id"` // The group voted in, defaults to the proposal's VoteGroupID
 }
 
-// XXX What about chainID?
-func (vote Vote) SignBytes() []byte { return wire.JSONBytes(vote) }
+func (vote Vote) SignBytes(chainID string) []byte {
+	return wire.JSONBytes(struct {
+		ChainID string `json:"chain_id"`
+		Vote    Vote   `json:"vote"`
+	}{chainID, vote})
+}
 
 type SignedVote struct {
 	Vote      Vote             `json:"vote"`
@@ -50,26 +158,170 @@ func NewSignedVote(vote Vote, sig crypto.Signature) SignedVote {
 	return SignedVote{vote, sig}
 }
 
+// A commitment to a vote on a secret ballot proposal.
+// The vote is revealed after the proposal's EndHeight.
+type VoteCommit struct {
+	Height     uint64 `json:"height"`
+	EntityAddr []byte `json:"entity_addr"`
+	ProposalID string `json:"proposal_id"`
+	Hash       []byte `json:"hash"` // VoteCommitHash of the vote
+}
+
+func (commit VoteCommit) SignBytes(chainID string) []byte {
+	return wire.JSONBytes(struct {
+		ChainID string     `json:"chain_id"`
+		Commit  VoteCommit `json:"commit"`
+	}{chainID, commit})
+}
+
+// The hash covers the chain, proposal, group and voter as well as the vote,
+// so that one voter can't copy and later reveal another voter's commit.
+// groupID is the group the vote is cast in.
+// credits are the quadratic vote credits, so that they can't be chosen at reveal.
+func VoteCommitHash(chainID string, proposalID string, groupID string,
+	entityAddr []byte, value string, credits uint64, salt string) []byte {
+	hash := sha256.Sum256(wire.BinaryBytes(struct {
+		ChainID    string
+		ProposalID string
+		GroupID    string
+		EntityAddr []byte
+		Value      string
+		Credits    uint64
+		Salt       string
+	}{chainID, proposalID, groupID, entityAddr, value, credits, salt}))
+	return hash[:]
+}
+
+type SignedVoteCommit struct {
+	Commit    VoteCommit       `json:"commit"`
+	Signature crypto.Signature `json:"signature"`
+}
+
 type Proposal struct {
-	ID          string       `json:"id"`
+	ID          string       `json:"id"` // Assigned by governmint, empty when proposed
 	VoteGroupID string       `json:"vote_group_id"`
 	StartHeight uint64       `json:"start_height"`
 	EndHeight   uint64       `json:"end_height"`
 	Info        ProposalInfo `json:"info"`
+
+	// Optional human context for the proposal.
+	// ContentHash is the hash of an off-chain document, e.g. the full text.
+	Title       string   `json:"title"`
+	Description string   `json:"description"`
+	Links       []string `json:"links"`
+	ContentHash []byte   `json:"content_hash"`
+
+	// If true, votes are committed until EndHeight and
+	// revealed during the following GovParams.RevealPeriod blocks.
+	SecretBallot bool `json:"secret_ballot"`
+
+	// Overrides the vote group's GroupPolicy.VotingMode if set.
+	VotingMode string `json:"voting_mode"`
+
+	// If true, the proposal passes at EndHeight unless members object
+	// by voting no, see GroupPolicy.ObjectionThreshold.
+	LazyConsensus bool `json:"lazy_consensus"`
+
+	// Groups that must also approve the proposal, e.g. for charter changes.
+	// Members vote separately in each group they belong to.
+	CoVoteGroups []ProposalVoteGroup `json:"co_vote_groups"`
+
+	// If EndTime is set, voting ends with the first block whose time is
+	// at or after EndTime instead of at EndHeight, which must be 0.
+	// Votes are only accepted in blocks with times from StartTime to EndTime.
+	// Times are in Unix seconds, as given to SetBlockTime.
+	StartTime uint64 `json:"start_time"`
+	EndTime   uint64 `json:"end_time"`
+
+	// If true, the admin group may vote on the proposal for as little as
+	// GovParams.EmergencyMinVotingPeriod blocks, but it must pass
+	// GovParams.EmergencyThreshold. It is executed as soon as it passes.
+	Emergency bool `json:"emergency"`
+}
+
+type ProposalVoteGroup struct {
+	GroupID   string   `json:"group_id"`
+	Threshold Fraction `json:"threshold"` // The zero value means the group's policy
 }
 
-func (proposal Proposal) SignBytes() []byte { return wire.JSONBytes(proposal) }
+func (proposal Proposal) SignBytes(chainID string) []byte {
+	return wire.JSONBytes(struct {
+		ChainID  string   `json:"chain_id"`
+		Proposal Proposal `json:"proposal"`
+	}{chainID, proposal})
+}
 
 type ActiveProposal struct {
-	Proposal    `json:"proposal"`
-	SignedVotes []SignedVote `json:"signed_votes"`
+	Proposal      `json:"proposal"`
+	SignedVotes   []SignedVote       `json:"signed_votes"`
+	SignedCommits []SignedVoteCommit `json:"signed_commits"` // Secret ballot only
+	Conviction    uint64             `json:"conviction"`     // Conviction proposals only, as of the last block
+	Proposer      []byte             `json:"proposer"`
+	Deposit       base.Coins         `json:"deposit"` // The coins sent with the proposal tx
+}
+
+// Voting power per vote value, counted when a proposal closes.
+type Tally struct {
+	Yes     uint64 `json:"yes"`
+	No      uint64 `json:"no"`
+	Abstain uint64 `json:"abstain"`
+	Total   uint64 `json:"total"` // Total voting power of the vote group
+
+	// Choice proposals only.
+	// For instant runoff, the counts are those of the final round.
+	Choices []ChoiceTally `json:"choices"`
+	Winner  string        `json:"winner"`
+
+	// Conviction proposals only.
+	Conviction uint64 `json:"conviction"`
+}
+
+type ChoiceTally struct {
+	Option string `json:"option"`
+	Power  uint64 `json:"power"`
+}
+
+// Vote credits of a member of a quadratic voting group.
+type VoteCredits struct {
+	Locked uint64 `json:"locked"` // Spent on active proposals
+}
+
+// A proposal that has been tallied and is no longer active.
+type ClosedProposal struct {
+	ActiveProposal `json:"active_proposal"`
+	Tally          Tally        `json:"tally"`      // Of the VoteGroupID
+	CoTallies      []GroupTally `json:"co_tallies"` // Of the CoVoteGroups
+	CloseHeight    uint64       `json:"close_height"`
+	Passed         bool         `json:"passed"`   // True if every vote group approved
+	Executed       bool         `json:"executed"` // False if passed but execution failed
+	Log            string       `json:"log"`
+
+	// A passed proposal with an execution delay, or of a child group,
+	// is executed at ExecuteHeight unless the parent group vetoes it first.
+	VetoGroupID   string `json:"veto_group_id"`
+	ExecuteHeight uint64 `json:"execute_height"`
+	Vetoed        bool   `json:"vetoed"`
+
+	// Closed without a tally, or not executed, as explained by Log.
+	Canceled bool `json:"canceled"`
+
+	// True if the proposal's Deposit is returned to the proposer,
+	// false if it is forfeited. See DepositSettlement.
+	DepositRefunded bool `json:"deposit_refunded"`
+}
+
+type GroupTally struct {
+	GroupID string `json:"group_id"`
+	Tally   Tally  `json:"tally"`
+	Passed  bool   `json:"passed"`
 }
 
 //----------------------------------------
 
 type GroupCreateProposalInfo struct {
-	NewGroupID string   `json:"new_group_id"` // The new group's ID
-	Members    []Member `json:"members"`      // The members of the new group
+	NewGroupID string       `json:"new_group_id"` // The new group's ID
+	Members    []Member     `json:"members"`      // The members of the new group
+	Charter    GroupCharter `json:"charter"`      // The new group's charter
 }
 
 type GroupUpdateProposalInfo struct {
@@ -78,6 +330,19 @@ type GroupUpdateProposalInfo struct {
 	ChangedMembers []Member `json:"changed_members"` // 0 VotingPower to remove
 }
 
+type GroupCharterProposalInfo struct {
+	UpdateGroupID string       `json:"update_group_id"` // The group to update
+	NextVersion   int          `json:"next_version"`    // The group's version, bumped 1
+	Charter       GroupCharter `json:"charter"`         // Replaces the group's charter
+}
+
+// Deletes a child group of the vote group.
+// Its children are given its parent, or if Cascade, deleted with it.
+type GroupDeleteProposalInfo struct {
+	DeleteGroupID string `json:"delete_group_id"` // The group to delete
+	Cascade       bool   `json:"cascade"`         // Also delete the group's descendants
+}
+
 type TextProposalInfo struct {
 	Text string `json:"text"`
 }
@@ -91,28 +356,97 @@ type UpgradeProposalInfo struct {
 	Modules []UpgradeProposalInfoModule
 }
 
+const (
+	ChoiceMethodPlurality     = "plurality"      // Vote value is a single option
+	ChoiceMethodInstantRunoff = "instant_runoff" // Vote value is a RankingValue of all options
+)
+
+// Choose one of several options, e.g. to elect a working group lead.
+type ChoiceProposalInfo struct {
+	Options []string `json:"options"`
+	Method  string   `json:"method"`
+}
+
+// Continuous funding, voted on by conviction.
+// There is no EndHeight, members' standing yes votes accrue conviction
+// (voting power times blocks held) every block, and the proposal passes
+// once conviction reaches Amount * GovParams.ConvictionFactor.
+// Voting again replaces the member's standing vote.
+type ConvictionProposalInfo struct {
+	Recipient []byte `json:"recipient"`
+	Amount    uint64 `json:"amount"`
+}
+
+// Options are separated by commas in a ranked vote's value.
+const RankingSeparator = ","
+
+// Returns the vote value for a ranking, most preferred first.
+func RankingValue(ranking []string) string {
+	return strings.Join(ranking, RankingSeparator)
+}
+
+func ParseRanking(value string) []string {
+	return strings.Split(value, RankingSeparator)
+}
+
 type ProposalInfo interface {
 	AssertIsProposalInfo()
 }
 
 const (
-	ProposalInfoTypeGroupCreate = byte(0x01)
-	ProposalInfoTypeGroupUpdate = byte(0x02)
-	ProposalInfoTypeText        = byte(0x11)
-	ProposalInfoTypeUpgrade     = byte(0x12)
+	ProposalInfoTypeGroupCreate  = byte(0x01)
+	ProposalInfoTypeGroupUpdate  = byte(0x02)
+	ProposalInfoTypeGroupCharter = byte(0x03)
+	ProposalInfoTypeGroupDelete  = byte(0x04)
+	ProposalInfoTypeText         = byte(0x11)
+	ProposalInfoTypeUpgrade      = byte(0x12)
+	ProposalInfoTypeChoice       = byte(0x13)
+	ProposalInfoTypeConviction   = byte(0x14)
 )
 
-func (_ *GroupCreateProposalInfo) AssertIsProposalInfo() {}
-func (_ *GroupUpdateProposalInfo) AssertIsProposalInfo() {}
-func (_ *TextProposalInfo) AssertIsProposalInfo()        {}
-func (_ *UpgradeProposalInfo) AssertIsProposalInfo()     {}
+func (_ *GroupCreateProposalInfo) AssertIsProposalInfo()  {}
+func (_ *GroupUpdateProposalInfo) AssertIsProposalInfo()  {}
+func (_ *GroupCharterProposalInfo) AssertIsProposalInfo() {}
+func (_ *GroupDeleteProposalInfo) AssertIsProposalInfo()  {}
+func (_ *TextProposalInfo) AssertIsProposalInfo()         {}
+func (_ *UpgradeProposalInfo) AssertIsProposalInfo()      {}
+func (_ *ChoiceProposalInfo) AssertIsProposalInfo()       {}
+func (_ *ConvictionProposalInfo) AssertIsProposalInfo()   {}
+
+// Returns the ProposalInfoType* of info, or 0 if unknown.
+func ProposalInfoTypeOf(info ProposalInfo) byte {
+	switch info.(type) {
+	case *GroupCreateProposalInfo:
+		return ProposalInfoTypeGroupCreate
+	case *GroupUpdateProposalInfo:
+		return ProposalInfoTypeGroupUpdate
+	case *GroupCharterProposalInfo:
+		return ProposalInfoTypeGroupCharter
+	case *GroupDeleteProposalInfo:
+		return ProposalInfoTypeGroupDelete
+	case *TextProposalInfo:
+		return ProposalInfoTypeText
+	case *UpgradeProposalInfo:
+		return ProposalInfoTypeUpgrade
+	case *ChoiceProposalInfo:
+		return ProposalInfoTypeChoice
+	case *ConvictionProposalInfo:
+		return ProposalInfoTypeConviction
+	default:
+		return 0
+	}
+}
 
 var _ = wire.RegisterInterface(
 	struct{ ProposalInfo }{},
 	wire.ConcreteType{&GroupCreateProposalInfo{}, ProposalInfoTypeGroupCreate},
 	wire.ConcreteType{&GroupUpdateProposalInfo{}, ProposalInfoTypeGroupUpdate},
+	wire.ConcreteType{&GroupCharterProposalInfo{}, ProposalInfoTypeGroupCharter},
+	wire.ConcreteType{&GroupDeleteProposalInfo{}, ProposalInfoTypeGroupDelete},
 	wire.ConcreteType{&TextProposalInfo{}, ProposalInfoTypeText},
 	wire.ConcreteType{&UpgradeProposalInfo{}, ProposalInfoTypeUpgrade},
+	wire.ConcreteType{&ChoiceProposalInfo{}, ProposalInfoTypeChoice},
+	wire.ConcreteType{&ConvictionProposalInfo{}, ProposalInfoTypeConviction},
 )
 
 //----------------------------------------
@@ -123,34 +457,242 @@ type ProposalTx struct {
 	Signature  crypto.Signature `json:"signature"`
 }
 
-func (tx *ProposalTx) SignBytes() []byte { return tx.Proposal.SignBytes() }
+func (tx *ProposalTx) SignBytes(chainID string) []byte { return tx.Proposal.SignBytes(chainID) }
 
 type VoteTx struct {
 	Vote      Vote             `json:"vote"`
 	Signature crypto.Signature `json:"signature"`
 }
 
-func (tx *VoteTx) SignBytes() []byte { return tx.Vote.SignBytes() }
+func (tx *VoteTx) SignBytes(chainID string) []byte { return tx.Vote.SignBytes(chainID) }
+
+type VoteCommitTx struct {
+	Commit    VoteCommit       `json:"commit"`
+	Signature crypto.Signature `json:"signature"`
+}
+
+func (tx *VoteCommitTx) SignBytes(chainID string) []byte { return tx.Commit.SignBytes(chainID) }
+
+// Reveals a committed vote. Vote.Height is the height of the reveal.
+type VoteRevealTx struct {
+	Vote      Vote             `json:"vote"`
+	Salt      string           `json:"salt"`
+	Signature crypto.Signature `json:"signature"`
+}
+
+func (tx *VoteRevealTx) SignBytes(chainID string) []byte { return tx.Vote.SignBytes(chainID) }
+
+// Vetoes a passed proposal before it is executed.
+// Signed by members of the proposal's VetoGroupID,
+// whose voting power must exceed the group's vote threshold.
+type VetoTx struct {
+	ProposalID string          `json:"proposal_id"`
+	Signatures []VetoSignature `json:"signatures"`
+}
+
+type VetoSignature struct {
+	EntityAddr []byte           `json:"entity_addr"`
+	Signature  crypto.Signature `json:"signature"`
+}
+
+func (tx *VetoTx) SignBytes(chainID string) []byte {
+	return wire.JSONBytes(struct {
+		ChainID    string `json:"chain_id"`
+		ProposalID string `json:"proposal_id"`
+	}{chainID, tx.ProposalID})
+}
+
+// Replaces the entity's profile. Signed by the entity itself.
+type EntityUpdateTx struct {
+	EntityAddr  []byte           `json:"entity_addr"`
+	NextVersion int              `json:"next_version"`
+	Profile     EntityProfile    `json:"profile"`
+	Signature   crypto.Signature `json:"signature"`
+}
+
+func (tx *EntityUpdateTx) SignBytes(chainID string) []byte {
+	return wire.JSONBytes(struct {
+		ChainID     string        `json:"chain_id"`
+		EntityAddr  []byte        `json:"entity_addr"`
+		NextVersion int           `json:"next_version"`
+		Profile     EntityProfile `json:"profile"`
+	}{chainID, tx.EntityAddr, tx.NextVersion, tx.Profile})
+}
 
 type Tx interface {
-	SignBytes() []byte
+	SignBytes(chainID string) []byte
 }
 
 const (
-	TxTypeProposal = byte(0x01)
-	TxTypeVote     = byte(0x02)
+	TxTypeProposal     = byte(0x01)
+	TxTypeVote         = byte(0x02)
+	TxTypeVoteCommit   = byte(0x03)
+	TxTypeVoteReveal   = byte(0x04)
+	TxTypeVeto         = byte(0x05)
+	TxTypeEntityUpdate = byte(0x06)
 )
 
 var _ = wire.RegisterInterface(
 	struct{ Tx }{},
 	wire.ConcreteType{&ProposalTx{}, TxTypeProposal},
 	wire.ConcreteType{&VoteTx{}, TxTypeVote},
+	wire.ConcreteType{&VoteCommitTx{}, TxTypeVoteCommit},
+	wire.ConcreteType{&VoteRevealTx{}, TxTypeVoteReveal},
+	wire.ConcreteType{&VetoTx{}, TxTypeVeto},
+	wire.ConcreteType{&EntityUpdateTx{}, TxTypeEntityUpdate},
+)
+
+//----------------------------------------
+
+type EntityQuery struct {
+	EntityAddr []byte `json:"entity_addr"`
+}
+
+type GroupQuery struct {
+	GroupID string `json:"group_id"`
+}
+
+type ActiveProposalQuery struct {
+	ProposalID string `json:"proposal_id"`
+}
+
+type GovMetaQuery struct {
+}
+
+type ClosedProposalQuery struct {
+	ProposalID string `json:"proposal_id"`
+}
+
+type VoteCreditsQuery struct {
+	GroupID    string `json:"group_id"`
+	EntityAddr []byte `json:"entity_addr"`
+}
+
+// Lists the passed proposals awaiting execution, as []PendingExecution.
+type PendingExecutionsQuery struct {
+}
+
+// Lists the deposits of the proposals that closed at Height, as []DepositSettlement.
+type DepositSettlementsQuery struct {
+	Height uint64 `json:"height"`
+}
+
+// Lists the members that haven't expired yet but will within Within blocks,
+// or at any height if Within is 0, as []MemberExpiration.
+type MemberExpirationsQuery struct {
+	Within uint64 `json:"within"`
+}
+
+type Query interface {
+	AssertIsQuery()
+}
+
+const (
+	QueryTypeEntity             = byte(0x01)
+	QueryTypeGroup              = byte(0x02)
+	QueryTypeActiveProposal     = byte(0x03)
+	QueryTypeGovMeta            = byte(0x04)
+	QueryTypeClosedProposal     = byte(0x05)
+	QueryTypeVoteCredits        = byte(0x06)
+	QueryTypePendingExecutions  = byte(0x07)
+	QueryTypeDepositSettlements = byte(0x08)
+	QueryTypeMemberExpirations  = byte(0x09)
+)
+
+func (_ *EntityQuery) AssertIsQuery()             {}
+func (_ *GroupQuery) AssertIsQuery()              {}
+func (_ *ActiveProposalQuery) AssertIsQuery()     {}
+func (_ *GovMetaQuery) AssertIsQuery()            {}
+func (_ *ClosedProposalQuery) AssertIsQuery()     {}
+func (_ *VoteCreditsQuery) AssertIsQuery()        {}
+func (_ *PendingExecutionsQuery) AssertIsQuery()  {}
+func (_ *DepositSettlementsQuery) AssertIsQuery() {}
+func (_ *MemberExpirationsQuery) AssertIsQuery()  {}
+
+var _ = wire.RegisterInterface(
+	struct{ Query }{},
+	wire.ConcreteType{&EntityQuery{}, QueryTypeEntity},
+	wire.ConcreteType{&GroupQuery{}, QueryTypeGroup},
+	wire.ConcreteType{&ActiveProposalQuery{}, QueryTypeActiveProposal},
+	wire.ConcreteType{&GovMetaQuery{}, QueryTypeGovMeta},
+	wire.ConcreteType{&ClosedProposalQuery{}, QueryTypeClosedProposal},
+	wire.ConcreteType{&VoteCreditsQuery{}, QueryTypeVoteCredits},
+	wire.ConcreteType{&PendingExecutionsQuery{}, QueryTypePendingExecutions},
+	wire.ConcreteType{&DepositSettlementsQuery{}, QueryTypeDepositSettlements},
+	wire.ConcreteType{&MemberExpirationsQuery{}, QueryTypeMemberExpirations},
 )
 
 //----------------------------------------
 
 type GovMeta struct {
-	Height uint64 // The current block height
+	ChainID        string // Set at genesis, included in sign bytes
+	Height         uint64 // The current block height
+	BlockTime      uint64 // The current block's time in Unix seconds, 0 if unknown
+	LastProposalID uint64 // Proposal IDs are assigned in increasing order from 1
+}
+
+// Governance parameters, settable at genesis.
+type GovParams struct {
+	MaxVotingPower uint64 `json:"max_voting_power"` // Max VotingPower of a member
+	RevealPeriod   uint64 `json:"reveal_period"`    // Blocks after EndHeight to reveal secret votes
+
+	// Conviction needed per unit of funds requested by a conviction proposal.
+	ConvictionFactor uint64 `json:"conviction_factor"`
+
+	// Blocks during which a parent group may veto a passed proposal
+	// of one of its child groups. 0 disables vetoes.
+	VetoPeriod uint64 `json:"veto_period"`
+
+	// Blocks between a proposal passing and its execution, by proposal type.
+	// Types that aren't listed are executed immediately.
+	ExecutionDelays []ExecutionDelay `json:"execution_delays"`
+
+	// Limits on a new proposal's voting period, from StartHeight to EndHeight,
+	// and on how far after the current height it may start.
+	MinVotingPeriod uint64 `json:"min_voting_period"`
+	MaxVotingPeriod uint64 `json:"max_voting_period"`
+	MaxStartDelay   uint64 `json:"max_start_delay"`
+
+	// Limits on the voting period of a proposal with an EndTime, in seconds.
+	MinVotingDuration uint64 `json:"min_voting_duration"`
+	MaxVotingDuration uint64 `json:"max_voting_duration"`
+
+	// Limits on concurrent active proposals, 0 means no limit.
+	MaxActiveProposalsPerProposer uint64 `json:"max_active_proposals_per_proposer"`
+	MaxActiveProposalsPerGroup    uint64 `json:"max_active_proposals_per_group"`
+
+	// Blocks after one of an entity's proposals is rejected
+	// before the entity may propose again, 0 means no cooldown.
+	RejectionCooldown uint64 `json:"rejection_cooldown"`
+
+	// Emergency proposals trade a shorter voting period for a higher threshold.
+	EmergencyMinVotingPeriod uint64   `json:"emergency_min_voting_period"`
+	EmergencyThreshold       Fraction `json:"emergency_threshold"`
+}
+
+type ExecutionDelay struct {
+	InfoType byte   `json:"info_type"` // A ProposalInfoType*
+	Delay    uint64 `json:"delay"`
+}
+
+type PendingExecution struct {
+	Height     uint64 `json:"height"`
+	ProposalID string `json:"proposal_id"`
+}
+
+// A closed proposal's deposit. Governmint doesn't hold coins, so returning
+// refunded deposits to the proposer is left to the host application.
+type DepositSettlement struct {
+	ProposalID string     `json:"proposal_id"`
+	Proposer   []byte     `json:"proposer"`
+	Deposit    base.Coins `json:"deposit"`
+	Refunded   bool       `json:"refunded"` // False if forfeited
+}
+
+type MemberExpiration struct {
+	ExpiresHeight uint64 `json:"expires_height"`
+	GroupID       string `json:"group_id"`
+	EntityAddr    []byte `json:"entity_addr"`
 }
 
 //----------------------------------------
@@ -163,10 +705,63 @@ func GroupKey(groupID string) []byte {
 	return []byte("gov/g/" + groupID)
 }
 
+// The IDs of all groups, in ascending order.
+func GroupIDsKey() []byte {
+	return []byte("gov/gids")
+}
+
 func ActiveProposalKey(proposalID string) []byte {
 	return []byte("gov/ap/" + proposalID)
 }
 
+// The IDs of all active proposals, in order of creation.
+func ActiveProposalIDsKey() []byte {
+	return []byte("gov/apids")
+}
+
+func ClosedProposalKey(proposalID string) []byte {
+	return []byte("gov/cp/" + proposalID)
+}
+
+// The ID of the proposal with the sign bytes hash, so that it can't be proposed again.
+func ProposalHashKey(hash []byte) []byte {
+	return append([]byte("gov/ph/"), hash...)
+}
+
+// The IDs of the closed proposals to execute at height.
+func PendingExecutionsKey(height uint64) []byte {
+	return []byte("gov/pe/" + strconv.FormatUint(height, 10))
+}
+
+// The heights with pending executions, in ascending order.
+func PendingExecutionHeightsKey() []byte {
+	return []byte("gov/peh")
+}
+
+// The height at which the entity's last proposal was rejected.
+func RejectionHeightKey(entityAddr []byte) []byte {
+	return append([]byte("gov/rj/"), entityAddr...)
+}
+
+func DepositSettlementsKey(height uint64) []byte {
+	return []byte("gov/ds/" + strconv.FormatUint(height, 10))
+}
+
+// The group ID is length prefixed, as it may contain "/".
+func VoteCreditsKey(groupID string, entityAddr []byte) []byte {
+	prefix := "gov/vc/" + strconv.Itoa(len(groupID)) + "/" + groupID
+	return append([]byte(prefix), entityAddr...)
+}
+
+// The addresses of the entities with vote credits in the group.
+func VoteCreditHoldersKey(groupID string) []byte {
+	return []byte("gov/vch/" + strconv.Itoa(len(groupID)) + "/" + groupID)
+}
+
 func GovMetaKey() []byte {
 	return []byte("gov/meta")
 }
+
+func GovParamsKey() []byte {
+	return []byte("gov/params")
+}